@@ -0,0 +1,203 @@
+// /////////////////////////////////////////////////////////////////////////////
+// BALANCE MODE FOR MULTI-CHANNEL DEVICES
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"     // format and print text
+	"math"    // scale channel volumes
+	"strings" // classify channel positions
+)
+
+// axis h/l move in balance mode
+const (
+	balanceOff    = iota // h/l change volume
+	balanceStereo        // left and right channels
+	balanceFade          // front and rear channels
+)
+
+const balanceStep = 0.1 // balance change of one key press, -1.0 to 1.0
+
+// side of an axis a channel sits on: -1 left/front, 1 right/rear, 0 neither
+func balanceSide(channel string, axis int) int {
+	if axis == balanceFade {
+		switch {
+		case strings.HasPrefix(channel, "front"):
+			return -1
+		case strings.HasPrefix(channel, "rear"):
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case strings.HasSuffix(channel, "left"):
+		return -1
+	case strings.HasSuffix(channel, "right"):
+		return 1
+	}
+	return 0
+}
+
+// loudest channel on each side of an axis, false if a side has no channels
+func balanceSides(d PulseDevice, axis int) (float64, float64, bool) {
+	var low, high float64
+	var hasLow, hasHigh bool
+	for i, c := range d.pulsechannels {
+		if i >= len(d.pulsevalue) {
+			break
+		}
+		v := float64(d.pulsevalue[i])
+		switch balanceSide(c, axis) {
+		case -1:
+			low, hasLow = math.Max(low, v), true
+		case 1:
+			high, hasHigh = math.Max(high, v), true
+		}
+	}
+	return low, high, hasLow && hasHigh
+}
+
+// balance of a device on an axis the way pulseaudio computes it
+func getAxisBalance(d PulseDevice, axis int) float64 {
+	low, high, ok := balanceSides(d, axis)
+	switch {
+	case !ok || low == high:
+		return 0
+	case low > high:
+		return high/low - 1
+	}
+	return 1 - low/high
+}
+
+// raw channel volumes for a new balance, both sides scaled to keep the power
+// of the loudest channels so the device sounds as loud, never past limit
+// unless a side is already louder
+func setAxisBalance(d PulseDevice, axis int, b, limit float64) []int {
+	low, high, _ := balanceSides(d, axis)
+	ratio := 1 - math.Abs(b) // quieter side to louder side, as getAxisBalance reads it
+	loud := math.Sqrt((low*low + high*high) / (1 + ratio*ratio))
+	loud = math.Min(loud, math.Max(limit, math.Max(low, high)))
+	newLow, newHigh := loud, loud
+	if b < 0 {
+		newHigh = loud * ratio
+	} else {
+		newLow = loud * ratio
+	}
+	vol := append([]int(nil), d.pulsevalue...)
+	for i, c := range d.pulsechannels {
+		if i >= len(vol) {
+			break
+		}
+		side, target := low, newLow
+		switch balanceSide(c, axis) {
+		case 0:
+			continue
+		case 1:
+			side, target = high, newHigh
+		}
+		if side == 0 { // a silent side has no shape to keep
+			vol[i] = int(math.Round(target))
+			continue
+		}
+		vol[i] = int(math.Round(float64(vol[i]) * target / side))
+	}
+	return vol
+}
+
+// check whether a device has channels on both sides of an axis
+func hasBalanceAxis(d PulseDevice, axis int) bool {
+	_, _, ok := balanceSides(d, axis)
+	return ok
+}
+
+// cycle balance mode: off, left/right, front/rear, skipping axes the device lacks
+func toggleBalance(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if isGroup(d) {
+		m.Message = "balance single streams"
+		return
+	}
+	m.ChannelMode = -1 // balance moves every channel
+	for m.Balance++; m.Balance <= balanceFade; m.Balance++ {
+		if hasBalanceAxis(d, m.Balance) {
+			m.Message = fmt.Sprintf("balance %v: %.2f", balanceName(m.Balance), getAxisBalance(d, m.Balance))
+			return
+		}
+	}
+	m.Balance = balanceOff
+	if !hasBalanceAxis(d, balanceStereo) && !hasBalanceAxis(d, balanceFade) {
+		m.Message = "no balance for this device"
+		return
+	}
+	m.Message = "balance off"
+}
+
+// name of a balance axis for messages
+func balanceName(axis int) string {
+	if axis == balanceFade {
+		return "front/rear"
+	}
+	return "left/right"
+}
+
+// labels at the ends of a balance axis
+func balanceEnds(axis int) (string, string) {
+	if axis == balanceFade {
+		return "F", "B"
+	}
+	return "L", "R"
+}
+
+// prepare raw channel volumes for h/l in balance mode, h moves left/front
+func formatBalanceStep(m *model, inc bool) []string {
+	d := m.Device[m.Cursor.pos]
+	step := -balanceStep
+	if inc {
+		step = balanceStep
+	}
+	b := math.Max(-1, math.Min(1, getAxisBalance(d, m.Balance)+step))
+	b = math.Round(b/balanceStep) * balanceStep // snap to steps after rounding drift
+	var vol []string
+	for _, v := range setAxisBalance(d, m.Balance, b, volumeLimit(m, d)/100*rawNorm) {
+		vol = append(vol, fmt.Sprint(v))
+	}
+	m.Message = fmt.Sprintf("balance %v: %.2f", balanceName(m.Balance), b)
+	return vol
+}
+
+// balance indicator shown under the bars of the device on cursor
+func displayBalance(m *model, d PulseDevice) string {
+	label := "      " // same width as displayCursor() and displayChannel()
+	if m.Display.level == 0 {
+		label = ""
+	}
+	b := getAxisBalance(d, m.Balance)
+	suffix := ""
+	if len(d.bar) > 0 && d.bar[0].ShowPercentage {
+		suffix = fmt.Sprintf(" %+.2f", b)
+		suffix += strings.Repeat(" ", int(math.Max(0, float64(labelWidth(m)-len(suffix)))))
+	}
+	start, end := balanceEnds(m.Balance)
+	width := m.StringLen - len([]rune(suffix)) - 4 // room for the end labels
+	if width < 3 {
+		return ""
+	}
+	line, center, knob := "─", "┼", "●"
+	if istty || setNoSymbol {
+		line, center, knob = "-", "+", "o"
+	}
+	mid := (width - 1) / 2
+	pos := int(math.Round(float64(mid) + b*float64(mid)))
+	var track string
+	for i := 0; i < width; i++ {
+		switch i {
+		case pos:
+			track += knob
+		case mid:
+			track += center
+		default:
+			track += line
+		}
+	}
+	return label + start + " " + track + " " + end + suffix
+}
@@ -0,0 +1,186 @@
+// /////////////////////////////////////////////////////////////////////////////
+// CHANNEL MAP LABELS, GROUPS AND SPATIAL LAYOUT
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"     // format and print text
+	"sort"    // order channels by group
+	"strconv" // number aux channels
+	"strings" // build labels and layout rows
+)
+
+// channel groups in display order
+const (
+	groupFront = iota
+	groupSide
+	groupRear
+	groupLFE
+	groupTop
+	groupAux
+)
+
+var groupNames = []string{"front", "side", "rear", "lfe", "top", "aux"}
+
+// short labels of pulseaudio channel positions, aux channels are numbered
+var channelLabels = map[string]string{
+	"mono":                  "M",
+	"front-left":            "L",
+	"front-right":           "R",
+	"front-center":          "C",
+	"front-left-of-center":  "FLC",
+	"front-right-of-center": "FRC",
+	"side-left":             "SL",
+	"side-right":            "SR",
+	"rear-left":             "RL",
+	"rear-right":            "RR",
+	"rear-center":           "RC",
+	"lfe":                   "LFE",
+	"top-center":            "TC",
+	"top-front-left":        "TFL",
+	"top-front-right":       "TFR",
+	"top-front-center":      "TFC",
+	"top-rear-left":         "TRL",
+	"top-rear-right":        "TRR",
+	"top-rear-center":       "TRC",
+}
+
+// label of a channel position, at most three characters
+func channelLabel(position string) string {
+	if l, ok := channelLabels[position]; ok {
+		return l
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(position, "aux")); err == nil && strings.HasPrefix(position, "aux") {
+		return "A" + strconv.Itoa(n)
+	}
+	if len(position) > 3 { // positions pulseaudio may add later
+		position = position[:3]
+	}
+	return strings.ToUpper(position)
+}
+
+// group a channel position belongs to
+func channelGroup(position string) int {
+	switch {
+	case position == "mono" || strings.HasPrefix(position, "front"):
+		return groupFront
+	case strings.HasPrefix(position, "side"):
+		return groupSide
+	case strings.HasPrefix(position, "rear"):
+		return groupRear
+	case position == "lfe":
+		return groupLFE
+	case strings.HasPrefix(position, "top"):
+		return groupTop
+	}
+	return groupAux
+}
+
+// channel indexes of a device in display order, grouped and otherwise as mapped
+func channelOrder(d PulseDevice) []int {
+	order := make([]int, len(d.pulsechannels))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return channelGroup(d.pulsechannels[order[a]]) < channelGroup(d.pulsechannels[order[b]])
+	})
+	return order
+}
+
+// line between the bars of a device, naming the group that starts below it
+func displayGroupDivider(d PulseDevice, prev, next int) string {
+	if channelGroup(d.pulsechannels[prev]) == channelGroup(d.pulsechannels[next]) {
+		return ""
+	}
+	rule := "──"
+	if istty || setNoSymbol {
+		rule = "--"
+	}
+	return fmt.Sprintf("%v %v %v", rule, groupNames[channelGroup(d.pulsechannels[next])], rule)
+}
+
+// move channel control to the next channel in display order, then to all channels
+func nextChannel(d PulseDevice, current int) int {
+	order := channelOrder(d)
+	if current < 0 {
+		return order[0]
+	}
+	for k, i := range order {
+		if i == current && k+1 < len(order) {
+			return order[k+1]
+		}
+	}
+	return -1
+}
+
+// check whether a device has channels around the listener worth a diagram
+func isSurround(d PulseDevice) bool {
+	for _, c := range d.pulsechannels {
+		switch channelGroup(c) {
+		case groupSide, groupRear, groupLFE, groupTop:
+			return true
+		}
+	}
+	return false
+}
+
+// toggle the spatial layout diagram of surround devices
+func toggleLayout(m *model) {
+	m.Layout = !m.Layout
+	if m.Layout {
+		m.Message = "channel layout on"
+		return
+	}
+	m.Message = "channel layout off"
+}
+
+// rows of a speaker diagram seen from above, front at the top
+var layoutRows = [][]string{
+	{"top-front-left", "top-front-center", "top-front-right"},
+	{"front-left", "front-left-of-center", "front-center", "front-right-of-center", "front-right"},
+	{"side-left", "", "side-right"},
+	{"top-rear-left", "top-center", "top-rear-center", "top-rear-right"},
+	{"rear-left", "rear-center", "rear-right"},
+	{"lfe"},
+}
+
+// speaker diagram of a surround device with the volume of each channel
+func displayLayout(m *model, d PulseDevice) []string {
+	volume := map[string]string{}
+	for i, c := range d.pulsechannels {
+		if i < len(d.pulsevolume) {
+			volume[c] = fmt.Sprintf("%v %.0f%%", channelLabel(c), d.pulsevolume[i])
+		}
+	}
+	listener := "◉"
+	if istty || setNoSymbol {
+		listener = "o"
+	}
+	var rows []string
+	for _, row := range layoutRows {
+		var cells []string
+		for _, c := range row {
+			if c == "" { // listener between the side channels
+				cells = append(cells, listener)
+				continue
+			}
+			if v, ok := volume[c]; ok {
+				cells = append(cells, v)
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, strings.Join(cells, "    "))
+		}
+	}
+	var aux []string
+	for _, c := range d.pulsechannels {
+		if channelGroup(c) == groupAux {
+			aux = append(aux, volume[c])
+		}
+	}
+	if len(aux) > 0 {
+		rows = append(rows, cutText(strings.Join(aux, "  "), m.StringLen))
+	}
+	return rows
+}
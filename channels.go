@@ -0,0 +1,166 @@
+// /////////////////////////////////////////////////////////////////////////////
+// CHANNEL LINKING AND TYPED VOLUME ENTRY
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"errors"                                     // report bad entries
+	"fmt"                                        // format and print text
+	"github.com/charmbracelet/bubbles/textinput" // volume entry prompt
+	tea "github.com/charmbracelet/bubbletea"     // main cli application library
+	"math"                                       // convert decibels
+	"os/exec"                                    // run external system commands
+	"strconv"                                    // parse typed values
+	"strings"                                    // trim typed values
+)
+
+const rawNorm = 65536 // raw pactl volume of 100%
+
+// check whether the channels of a device keep their own offsets
+func isUnlinked(m *model, d PulseDevice) bool {
+	return m.Unlinked[meterKey(d)]
+}
+
+// loudest channel of a device
+func loudestChannel(d PulseDevice) float64 {
+	var top float64
+	for _, v := range d.pulsevolume {
+		top = math.Max(top, v)
+	}
+	return top
+}
+
+// volume a channel is clamped against when all channels move, unlinked devices
+// stop at the limit together so their offsets survive
+func linkedVolume(m *model, d PulseDevice, channel int) float64 {
+	if isUnlinked(m, d) {
+		return loudestChannel(d)
+	}
+	return d.pulsevolume[channel]
+}
+
+// link or unlink the channels of the device on cursor
+func toggleLink(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if isGroup(d) {
+		m.Message = "unlink single streams"
+		return
+	}
+	if d.pulsecount < 2 {
+		m.Message = "single channel device"
+		return
+	}
+	key := meterKey(d)
+	if m.Unlinked[key] {
+		delete(m.Unlinked, key)
+		m.Message = fmt.Sprintf("channels linked: %v", d.pulsedescription)
+		return
+	}
+	m.Unlinked[key] = true
+	m.Message = fmt.Sprintf("channels unlinked: %v", d.pulsedescription)
+}
+
+// icon shown in front of devices with unlinked channels
+func displayLink(m *model, d PulseDevice) string {
+	if !isUnlinked(m, d) {
+		return ""
+	}
+	return link_icon
+}
+
+// raw pactl values that put a device at a volume, one per channel
+// the chosen channel alone if any, all channels equal when linked, or the
+// loudest channel at the volume with the others keeping their offsets
+func channelTargets(m *model, d PulseDevice, target float64) []string {
+	var vol []string
+	for i, v := range d.pulsevolume {
+		switch {
+		case m.ChannelMode >= 0 && m.ChannelMode != i: // keep excluded channels
+			if i < len(d.pulsevalue) {
+				vol = append(vol, strconv.Itoa(d.pulsevalue[i]))
+				continue
+			}
+		case m.ChannelMode < 0 && isUnlinked(m, d):
+			v = math.Max(0, v+target-loudestChannel(d))
+		default:
+			v = target
+		}
+		vol = append(vol, strconv.Itoa(int(math.Round(v*rawNorm/100))))
+	}
+	return vol
+}
+
+// read a typed volume as percent: 72, 72%, -6dB or -inf dB
+func parseVolume(s string) (float64, error) {
+	s = strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	if strings.HasSuffix(s, "db") {
+		s = strings.TrimSuffix(s, "db")
+		if s == "-inf" {
+			return 0, nil
+		}
+		db, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, errors.New("not a decibel value")
+		}
+		return 100 * math.Pow(10, db/60), nil // inverse of percentToDB
+	}
+	p, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || p < 0 {
+		return 0, errors.New("not a volume")
+	}
+	return p, nil
+}
+
+// open the prompt for an exact volume of the chosen channel or all channels
+func startEntry(m *model) tea.Cmd {
+	d := m.Device[m.Cursor.pos]
+	m.Entry = textinput.New()
+	m.Entry.Prompt = "all channels: "
+	if m.ChannelMode >= 0 {
+		m.Entry.Prompt = d.pulsechannels[m.ChannelMode] + ": "
+	}
+	m.Entry.Placeholder = "72 or -6dB"
+	m.Entry.CharLimit = 12
+	m.Entry.Width = 12
+	m.Entering = true
+	return m.Entry.Focus()
+}
+
+// send keys to the prompt, enter applies and escape closes it
+func updateEntry(m *model, msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.Entering = false
+		m.Message = "volume entry cancelled"
+		return nil
+	case tea.KeyEnter:
+		m.Entering = false
+		cancelFade(m)
+		value := m.Entry.Value()
+		forEachStream(m, func(m *model) { applyEntry(m, value) })
+		return updateDevices(*m)
+	}
+	var cmd tea.Cmd
+	m.Entry, cmd = m.Entry.Update(msg)
+	return cmd
+}
+
+// set the typed volume with one pactl call, respecting the volume limit
+func applyEntry(m *model, s string) {
+	d := m.Device[m.Cursor.pos]
+	p, err := parseVolume(s)
+	if err != nil {
+		m.Message = fmt.Sprintf("%v: %q", err, s)
+		return
+	}
+	if max := volumeLimit(m, d); p > max {
+		p = max
+	}
+	args := append([]string{volumeCmd(d.pulsetype), strconv.Itoa(d.pulseindex)}, channelTargets(m, d, p)...)
+	if err := exec.Command(pactl, args...).Run(); err != nil {
+		m.Message = "error setting volume"
+		m.Err = err
+		return
+	}
+	m.Message = fmt.Sprintf("volume set to %.0f%%", p)
+}
@@ -0,0 +1,431 @@
+// /////////////////////////////////////////////////////////////////////////////
+// HEADLESS COMMAND LINE SUBCOMMANDS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/json"                      // print devices as json
+	"fmt"                                // format and print text
+	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
+	"os"                                 // write to stdout/stderr
+	"strconv"                            // convert types to/from string
+	"strings"                            // manipulate strings
+)
+
+// exit codes returned by subcommands
+const (
+	exitOK        = 0 // command succeeded
+	exitFailed    = 1 // pactl reported an error
+	exitUsage     = 2 // missing or invalid arguments
+	exitNoDevice  = 3 // no device matched
+	exitAmbiguous = 4 // more than one device matched
+)
+
+// subcommand names mapped to their handlers
+var commands = map[string]func(args []string) int{
+	"list":     listCommand,
+	"volume":   volumeCommand,
+	"mute":     muteCommand,
+	"default":  defaultCommand,
+	"move":     moveCommand,
+	"loopback": loopbackCommand,
+	"status":   statusCommand,
+	"ctl":      ctlCommand,
+	"serve":    serveCommand,
+	"metrics":  metricsCommand,
+	"daemon":   daemonCommand,
+}
+
+// check whether a program flag needs a value after it
+func takesValue(f *flag.Flag) bool {
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// position of the subcommand in args, after any program flags in front of it,
+// -1 if the program was launched without one
+func commandIndex(args []string) int {
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return -1
+		case strings.HasPrefix(a, "--"): // --flag value or --flag=value
+			if !strings.Contains(a, "=") && takesValue(flag.Lookup(a[2:])) {
+				i++
+			}
+		case strings.HasPrefix(a, "-") && len(a) > 1: // -m 150, -m150 or -fu
+			if takesValue(flag.ShorthandLookup(a[1:2])) {
+				if len(a) == 2 {
+					i++
+				}
+			} else if takesValue(flag.ShorthandLookup(a[len(a)-1:])) {
+				i++ // last of combined shorthands takes the next argument
+			}
+		default:
+			if _, ok := commands[a]; ok {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// check whether the program was launched with a subcommand
+func isCommand(args []string) bool {
+	return commandIndex(args) > 0
+}
+
+// apply the program flags in front of the subcommand, run it and return its exit code
+func runCommand(args []string) int {
+	i := commandIndex(args)
+	if err := flag.CommandLine.Parse(args[1:i]); err != nil {
+		return exitUsage
+	}
+	validateFlags()
+	if setNoSymbol {
+		disableSymbols()
+	}
+	return commands[args[i]](args[i+1:])
+}
+
+// json representation of a PulseDevice
+type DeviceJSON struct {
+	Type        string    `json:"type"`
+	Index       int       `json:"index"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	State       string    `json:"state,omitempty"`
+	Mute        bool      `json:"mute"`
+	Channels    []string  `json:"channels"`
+	Volume      []float64 `json:"volume"`
+	Value       []int     `json:"value"`
+	BaseVolume  float64   `json:"base_volume,omitempty"`
+	Balance     float64   `json:"balance"`
+	Sink        *int      `json:"sink,omitempty"`
+	Source      *int      `json:"source,omitempty"`
+	Driver      string    `json:"driver,omitempty"`
+	Module      string    `json:"module,omitempty"`
+	SampleSpec  string    `json:"sample_spec,omitempty"`
+	Port        string    `json:"port,omitempty"`
+	Battery     string    `json:"battery,omitempty"`
+	Latency     float64   `json:"latency_usec,omitempty"`
+}
+
+// convert a PulseDevice into its json representation
+func exportDevice(d PulseDevice) DeviceJSON {
+	j := DeviceJSON{
+		Type:        getDeviceType(d.pulsetype),
+		Index:       d.pulseindex,
+		Name:        d.pulsename,
+		Description: d.pulsedescription,
+		State:       d.pulsestate,
+		Mute:        d.pulsemute,
+		Channels:    d.pulsechannels,
+		Volume:      d.pulsevolume,
+		Value:       d.pulsevalue,
+		BaseVolume:  d.pulsebase,
+		Balance:     d.pulsebalance,
+		Driver:      d.pulsedriver,
+		Module:      d.pulsemodule,
+		SampleSpec:  d.pulsesamplerate,
+		Port:        d.pulseport,
+		Battery:     d.pulsebattery,
+		Latency:     d.pulselatency,
+	}
+	switch d.pulsetype {
+	case pulsestream:
+		sink := d.pulsesinkindex
+		j.Sink = &sink
+	case pulseoutput:
+		source := d.pulsesourceindex
+		j.Source = &source
+	}
+	return j
+}
+
+// build a model with current devices but no terminal attached
+func headlessModel() model {
+	d, dc := buildDevices(buildPulse())
+	return model{
+		Device:      d,
+		Count:       dc,
+		ChannelMode: -1,
+		Latency:     varLatency,
+		VolumeLimit: setMaxVolume,
+		Selected:    initSelection(),
+		Peaks:       map[string]float64{},
+	}
+}
+
+// find a device by index, name or description substring
+// a "type:" prefix (sink:, stream:, source:, output:) restricts the search
+func matchDevice(m *model, query string, types ...int) (int, int) {
+	for t := pulsesink; t <= pulseoutput; t++ {
+		prefix := getDeviceType(t) + ":"
+		if strings.HasPrefix(query, prefix) {
+			query = strings.TrimPrefix(query, prefix)
+			types = []int{t}
+		}
+	}
+	allowed := func(t int) bool {
+		if len(types) == 0 {
+			return t != pulsecard
+		}
+		for _, v := range types {
+			if v == t {
+				return true
+			}
+		}
+		return false
+	}
+	var byIndex, byName, byDescription []int
+	num, numErr := strconv.Atoi(query)
+	lower := strings.ToLower(query)
+	for i, d := range m.Device {
+		if !allowed(d.pulsetype) {
+			continue
+		}
+		if numErr == nil && d.pulseindex == num {
+			byIndex = append(byIndex, i)
+		}
+		if d.pulsename == query {
+			byName = append(byName, i)
+		}
+		if strings.Contains(strings.ToLower(d.pulsedescription), lower) ||
+			strings.Contains(strings.ToLower(d.pulsename), lower) {
+			byDescription = append(byDescription, i)
+		}
+	}
+	for _, found := range [][]int{byIndex, byName, byDescription} {
+		switch {
+		case len(found) == 1:
+			return found[0], exitOK
+		case len(found) > 1:
+			return -1, exitAmbiguous
+		}
+	}
+	return -1, exitNoDevice
+}
+
+// report a failed device match and return its exit code
+func matchFailed(query string, code int) int {
+	if code == exitAmbiguous {
+		fmt.Fprintf(os.Stderr, "more than one device matches %q\n", query)
+	} else {
+		fmt.Fprintf(os.Stderr, "no device matches %q\n", query)
+	}
+	return code
+}
+
+// print the result of a device command and return its exit code
+func commandResult(m *model) int {
+	if m.Err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", m.Message, m.Err)
+		return exitFailed
+	}
+	if m.Message != "" {
+		fmt.Println(m.Message)
+	}
+	return exitOK
+}
+
+// print usage of a subcommand and return the usage exit code
+func commandUsage(usage string) int {
+	fmt.Fprintf(os.Stderr, "usage: pulsemanager %v\n", usage)
+	return exitUsage
+}
+
+// pulsemanager list [--json]
+func listCommand(args []string) int {
+	var asJSON bool
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.BoolVarP(&asJSON, "json", "j", false, "print devices as json")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	m := headlessModel()
+	var list []DeviceJSON
+	for _, d := range m.Device {
+		if d.pulsetype == pulsecard {
+			continue
+		}
+		list = append(list, exportDevice(d))
+	}
+	if asJSON {
+		out, _ := json.MarshalIndent(list, "", "  ")
+		fmt.Println(string(out))
+		return exitOK
+	}
+	for _, d := range list {
+		var vol []string
+		for _, v := range d.Volume {
+			vol = append(vol, fmt.Sprintf("%v%%", v))
+		}
+		mute := ""
+		if d.Mute {
+			mute = " muted"
+		}
+		fmt.Printf("%-6v %-4v %v [%v]%v\n", d.Type, d.Index, d.Description, strings.Join(vol, " "), mute)
+	}
+	return exitOK
+}
+
+// set the volume of the device on cursor from "+N%", "-N%" or "N%"
+// returns false if the value cannot be parsed
+func applyVolume(m *model, value string) bool {
+	value = strings.TrimSuffix(value, "%")
+	n, err := strconv.ParseFloat(strings.TrimLeft(value, "+-"), 64)
+	if err != nil || n < 0 {
+		return false
+	}
+	d := m.Device[m.Cursor.pos]
+	switch {
+	case strings.HasPrefix(value, "+"):
+		changeDeviceVolume(m, formatVolumeStep(m, true, n))
+		if m.Err == nil {
+			m.Message = fmt.Sprintf("volume raised %v%%: %v", n, d.pulsedescription)
+		}
+	case strings.HasPrefix(value, "-"):
+		changeDeviceVolume(m, formatVolumeStep(m, false, n))
+		if m.Err == nil {
+			m.Message = fmt.Sprintf("volume lowered %v%%: %v", n, d.pulsedescription)
+		}
+	default:
+		normalizeDeviceVolume(m, int(n))
+	}
+	return true
+}
+
+// pulsemanager volume <device> <+N%|-N%|N%>
+func volumeCommand(args []string) int {
+	usage := "volume <device> <+N%|-N%|N%>"
+	if len(args) != 2 {
+		return commandUsage(usage)
+	}
+	m := headlessModel()
+	pos, code := matchDevice(&m, args[0])
+	if code != exitOK {
+		return matchFailed(args[0], code)
+	}
+	m.Cursor.pos = pos
+	if !applyVolume(&m, args[1]) {
+		return commandUsage(usage)
+	}
+	return commandResult(&m)
+}
+
+// pulsemanager mute <device> [toggle|on|off]
+func muteCommand(args []string) int {
+	usage := "mute <device> [toggle|on|off]"
+	if len(args) < 1 || len(args) > 2 {
+		return commandUsage(usage)
+	}
+	state := toggle
+	if len(args) == 2 {
+		state = args[1]
+	}
+	if state != toggle && state != "on" && state != "off" {
+		return commandUsage(usage)
+	}
+	m := headlessModel()
+	pos, code := matchDevice(&m, args[0])
+	if code != exitOK {
+		return matchFailed(args[0], code)
+	}
+	m.Cursor.pos = pos
+	muted := m.Device[pos].pulsemute
+	if (state == "on" && muted) || (state == "off" && !muted) {
+		fmt.Printf("unchanged: %v\n", m.Device[pos].pulsedescription)
+		return exitOK
+	}
+	toggleDeviceMute(&m)
+	return commandResult(&m)
+}
+
+// pulsemanager default <sink|source> <device>
+func defaultCommand(args []string) int {
+	usage := "default <sink|source> <device>"
+	if len(args) != 2 {
+		return commandUsage(usage)
+	}
+	m := headlessModel()
+	switch args[0] {
+	case "sink":
+		pos, code := matchDevice(&m, args[1], pulsesink)
+		if code != exitOK {
+			return matchFailed(args[1], code)
+		}
+		m.Cursor.pos = pos
+		changeDefaultSink(&m)
+	case "source":
+		pos, code := matchDevice(&m, args[1], pulsesource)
+		if code != exitOK {
+			return matchFailed(args[1], code)
+		}
+		m.Cursor.pos = pos
+		changeDefaultSource(&m)
+	default:
+		return commandUsage(usage)
+	}
+	return commandResult(&m)
+}
+
+// pulsemanager move <stream|output> <sink|source>
+func moveCommand(args []string) int {
+	if len(args) != 2 {
+		return commandUsage("move <stream|output> <sink|source>")
+	}
+	m := headlessModel()
+	pos, code := matchDevice(&m, args[0], pulsestream, pulseoutput)
+	if code != exitOK {
+		return matchFailed(args[0], code)
+	}
+	target := pulsesink
+	if m.Device[pos].pulsetype == pulseoutput {
+		target = pulsesource
+	}
+	dest, code := matchDevice(&m, args[1], target)
+	if code != exitOK {
+		return matchFailed(args[1], code)
+	}
+	m.Cursor.pos = dest
+	selectDevice(&m)
+	m.Cursor.pos = pos
+	if target == pulsesink {
+		moveStreamToSink(&m)
+	} else {
+		moveOutputToSource(&m)
+	}
+	return commandResult(&m)
+}
+
+// pulsemanager loopback <source> <sink> [--latency N]
+func loopbackCommand(args []string) int {
+	usage := "loopback <source> <sink> [--latency msec]"
+	latency := varLatency
+	fs := flag.NewFlagSet("loopback", flag.ContinueOnError)
+	fs.IntVarP(&latency, "latency", "l", varLatency, "loopback latency in milliseconds")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return commandUsage(usage)
+	}
+	if latency < minLatency || latency > maxLatency {
+		fmt.Fprintf(os.Stderr, "latency must be %v-%v milliseconds\n", minLatency, maxLatency)
+		return exitUsage
+	}
+	m := headlessModel()
+	m.Latency = latency
+	source, code := matchDevice(&m, fs.Arg(0), pulsesource)
+	if code != exitOK {
+		return matchFailed(fs.Arg(0), code)
+	}
+	sink, code := matchDevice(&m, fs.Arg(1), pulsesink)
+	if code != exitOK {
+		return matchFailed(fs.Arg(1), code)
+	}
+	m.Cursor.pos = sink
+	selectDevice(&m)
+	m.Cursor.pos = source
+	loopbackSourceToSink(&m)
+	return commandResult(&m)
+}
@@ -8,37 +8,72 @@ import (
 	"github.com/charmbracelet/lipgloss"  // style application
 	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
 	"github.com/spf13/viper"             // manage configuration of app
+	"os"                                 // read environment variables
+	"path/filepath"                      // join configured paths
+	"regexp"                             // compile device limit patterns
+	"strings"                            // manipulate strings
 )
 
 // final values passed to tea.Model on Initialization
 var (
-	setMaxVolume  float64 // maximum volume
-	setVolume     float64 // volume adjustment amount
-	setNoMessages bool    // program displays messages
-	setAltscreen  bool    // program starts fullscreen
-	setItems      int     // number of items per page
-	setWidth      int     // width of the application - 2 for safety
-	setNoColor    bool    // is NO_COLOR set on system?
-	setNoHelp     bool    // hide help model
-	setNoTitle    bool    // hide title
-	setBorder     = lipgloss.NormalBorder()
-	setNoSymbol   bool // do not use unicode symbols
-	setDisplay    int  // device display level
+	setMaxVolume    float64 // maximum volume
+	setMaxVolumeArg bool    // maximum volume given with --max-volume, wins over Limits
+	setVolume       float64 // volume adjustment amount
+	setNoMessages   bool    // program displays messages
+	setAltscreen    bool    // program starts fullscreen
+	setItems        int     // most devices shown at once, 0 for no limit
+	setWidth        int     // width of the application - 2 for safety
+	setNoColor      bool    // is NO_COLOR set on system?
+	setNoHelp       bool    // hide help model
+	setNoTitle      bool    // hide title
+	setBorder       = lipgloss.NormalBorder()
+	setNoSymbol     bool              // do not use unicode symbols
+	setDisplay      int               // device display level
+	setMeters       bool              // show live peak meters
+	setMeterRate    int               // peak meter frames per second
+	setRecordDir    string            // directory for wav recordings
+	setTestSignal   string            // speaker test tone or noise
+	setTestStep     int               // seconds speaker test stays on each channel
+	setHTTPAddr     string            // address of local http api, empty to disable
+	setMetricsAddr  string            // address of prometheus exporter, empty to disable
+	setHooks        map[string]string // shell commands run on audio events
+	setHookTimeout  int               // seconds before a hook is killed
+	setBatteryLow   int               // battery level that fires battery-low
+	setNotify       string            // send, log or turn off notifications
+	setLocks        []VolumeLock      // volume locks from the config file
+	setTypeLimits   [4]VolumeSettings // limit and step of sinks, streams, sources, outputs
+	setDeviceLimits []DeviceLimit     // limit and step overrides for matching devices
+	setVolumeFormat string            // label bars in percent, db or both
+	setDecibelStep  int               // decibels per h/l press in dB step mode
+	setFadeTime     int               // seconds a shift+digit fade takes
+	setSleepTimer   int               // minutes before the sleep timer fades out
 )
 
 // flag variables used for command line parsing and validation
 var (
-	configFlag     bool
-	fullscreenFlag bool
-	messagesFlag   bool
-	titleFlag      bool
-	helpFlag       bool
-	setVolumeFlag  int
-	maxVolumeFlag  int
-	setItemsFlag   int
-	setWidthFlag   int
-	symbolsFlag    bool
-	displayFlag    int
+	configFlag       bool
+	fullscreenFlag   bool
+	messagesFlag     bool
+	titleFlag        bool
+	helpFlag         bool
+	setVolumeFlag    int
+	maxVolumeFlag    int
+	setItemsFlag     int
+	setWidthFlag     int
+	symbolsFlag      bool
+	displayFlag      int
+	metersFlag       bool
+	meterRateFlag    int
+	recordDirFlag    string
+	testSignalFlag   string
+	testStepFlag     int
+	httpAddrFlag     string
+	metricsAddrFlag  string
+	notifyFlag       string
+	volumeFormatFlag string
+	decibelStepFlag  int
+	fadeTimeFlag     int
+	sleepTimerFlag   int
 )
 
 // define the default settings for both flags and config file
@@ -48,11 +83,23 @@ func initDefaults() Config {
 	c.Settings.NoMessage = false
 	c.Settings.NoHelp = false
 	c.Settings.Width = 100
-	c.Settings.Items = 4
+	c.Settings.Items = 0
 	c.Settings.VolumeLimit = 110
 	c.Settings.VolumeSteps = 5
 	c.Settings.NoSymbols = false
 	c.Settings.DeviceDisplay = 3
+	c.Settings.Meters = false
+	c.Settings.MeterRate = 20
+	c.Settings.RecordDir = "$HOME/Recordings"
+	c.Settings.TestSignal = testSine
+	c.Settings.TestStep = 2
+	c.Settings.HTTPAddr = ""
+	c.Settings.MetricsAddr = ""
+	c.Settings.Notify = notifyOff
+	c.Settings.VolumeFormat = formatPercent
+	c.Settings.DecibelSteps = 3
+	c.Settings.FadeTime = 3
+	c.Settings.SleepTimer = 30
 	return c
 }
 
@@ -68,6 +115,18 @@ func setDefaults(d Config) {
 	viper.SetDefault("volume-steps", d.Settings.VolumeSteps)
 	viper.SetDefault("no-symbols", d.Settings.NoSymbols)
 	viper.SetDefault("device-display", d.Settings.DeviceDisplay)
+	viper.SetDefault("meters", d.Settings.Meters)
+	viper.SetDefault("meter-rate", d.Settings.MeterRate)
+	viper.SetDefault("record-dir", d.Settings.RecordDir)
+	viper.SetDefault("test-signal", d.Settings.TestSignal)
+	viper.SetDefault("test-step", d.Settings.TestStep)
+	viper.SetDefault("http-addr", d.Settings.HTTPAddr)
+	viper.SetDefault("metrics-addr", d.Settings.MetricsAddr)
+	viper.SetDefault("notify", d.Settings.Notify)
+	viper.SetDefault("volume-format", d.Settings.VolumeFormat)
+	viper.SetDefault("db-steps", d.Settings.DecibelSteps)
+	viper.SetDefault("fade-time", d.Settings.FadeTime)
+	viper.SetDefault("sleep-timer", d.Settings.SleepTimer)
 }
 
 // get color values from configuration file
@@ -123,6 +182,39 @@ func validateConfig(c *Config) {
 	if c.Settings.DeviceDisplay > maxConfigDisplay {
 		c.Settings.DeviceDisplay = viper.GetInt("device-display")
 	}
+	if c.Settings.MeterRate < minConfigRate {
+		c.Settings.MeterRate = viper.GetInt("meter-rate")
+	}
+	if c.Settings.MeterRate > maxConfigRate {
+		c.Settings.MeterRate = viper.GetInt("meter-rate")
+	}
+	if c.Settings.RecordDir == "" {
+		c.Settings.RecordDir = viper.GetString("record-dir")
+	}
+	if c.Settings.TestSignal != testSine && c.Settings.TestSignal != testNoise {
+		c.Settings.TestSignal = viper.GetString("test-signal")
+	}
+	if c.Settings.TestStep < minConfigStep {
+		c.Settings.TestStep = viper.GetInt("test-step")
+	}
+	if c.Settings.TestStep > maxConfigStep {
+		c.Settings.TestStep = viper.GetInt("test-step")
+	}
+	if !isNotifyMode(c.Settings.Notify) {
+		c.Settings.Notify = viper.GetString("notify")
+	}
+	if !isVolumeFormat(c.Settings.VolumeFormat) {
+		c.Settings.VolumeFormat = viper.GetString("volume-format")
+	}
+	if c.Settings.DecibelSteps < minConfigDecibel || c.Settings.DecibelSteps > maxConfigDecibel {
+		c.Settings.DecibelSteps = viper.GetInt("db-steps")
+	}
+	if c.Settings.FadeTime < minConfigFade || c.Settings.FadeTime > maxConfigFade {
+		c.Settings.FadeTime = viper.GetInt("fade-time")
+	}
+	if c.Settings.SleepTimer < minConfigSleep || c.Settings.SleepTimer > maxConfigSleep {
+		c.Settings.SleepTimer = viper.GetInt("sleep-timer")
+	}
 	viper.Set("fullscreen", c.Settings.Fullscreen)
 	viper.Set("no-message", c.Settings.NoMessage)
 	viper.Set("no-help", c.Settings.NoHelp)
@@ -133,6 +225,18 @@ func validateConfig(c *Config) {
 	viper.Set("volume-steps", c.Settings.VolumeSteps)
 	viper.Set("no-symbols", c.Settings.NoSymbols)
 	viper.Set("device-display", c.Settings.DeviceDisplay)
+	viper.Set("meters", c.Settings.Meters)
+	viper.Set("meter-rate", c.Settings.MeterRate)
+	viper.Set("record-dir", c.Settings.RecordDir)
+	viper.Set("test-signal", c.Settings.TestSignal)
+	viper.Set("test-step", c.Settings.TestStep)
+	viper.Set("http-addr", c.Settings.HTTPAddr)
+	viper.Set("metrics-addr", c.Settings.MetricsAddr)
+	viper.Set("notify", c.Settings.Notify)
+	viper.Set("volume-format", c.Settings.VolumeFormat)
+	viper.Set("db-steps", c.Settings.DecibelSteps)
+	viper.Set("fade-time", c.Settings.FadeTime)
+	viper.Set("sleep-timer", c.Settings.SleepTimer)
 }
 func initFlags() {
 	// flag creation and validation; flag defaults are passed from validateConfig()
@@ -140,12 +244,24 @@ func initFlags() {
 	flag.BoolVarP(&helpFlag, "no-help", "H", viper.GetBool("no-help"), "hide help text")
 	flag.BoolVarP(&titleFlag, "no-title", "t", viper.GetBool("no-title"), "hide program name")
 	flag.BoolVarP(&messagesFlag, "no-messages", "v", viper.GetBool("no-message"), "hide program messages")
-	flag.IntVarP(&setItemsFlag, "max-items", "i", viper.GetInt("items"), "set devices per page")
+	flag.IntVarP(&setItemsFlag, "max-items", "i", viper.GetInt("items"), "set most devices shown at once, 0 for no limit")
 	flag.IntVarP(&setWidthFlag, "max-width", "w", viper.GetInt("width"), "set width of program in terminal")
 	flag.IntVarP(&maxVolumeFlag, "max-volume", "m", viper.GetInt("volume-limit"), "set maximum volume for devices")
 	flag.IntVarP(&setVolumeFlag, "volume-steps", "s", viper.GetInt("volume-steps"), "set volume increments")
 	flag.BoolVarP(&symbolsFlag, "no-symbols", "u", viper.GetBool("no-symbols"), "disable unicode symbols")
 	flag.IntVarP(&displayFlag, "device-display", "d", viper.GetInt("device-display"), "device display level")
+	flag.BoolVarP(&metersFlag, "meters", "M", viper.GetBool("meters"), "show live peak meters")
+	flag.IntVarP(&meterRateFlag, "meter-rate", "r", viper.GetInt("meter-rate"), "set peak meter frames per second")
+	flag.StringVarP(&recordDirFlag, "record-dir", "o", viper.GetString("record-dir"), "set directory for recordings")
+	flag.StringVarP(&testSignalFlag, "test-signal", "T", viper.GetString("test-signal"), "speaker test signal (tone or noise)")
+	flag.IntVarP(&testStepFlag, "test-step", "S", viper.GetInt("test-step"), "set seconds per channel in speaker test")
+	flag.StringVarP(&httpAddrFlag, "http-addr", "a", viper.GetString("http-addr"), "serve http api on host:port or unix:/path")
+	flag.StringVarP(&metricsAddrFlag, "metrics-addr", "p", viper.GetString("metrics-addr"), "serve prometheus metrics on host:port or unix:/path")
+	flag.StringVarP(&notifyFlag, "notify", "n", viper.GetString("notify"), "desktop notifications (send, log or off)")
+	flag.StringVarP(&volumeFormatFlag, "volume-format", "D", viper.GetString("volume-format"), "label volume in percent, db or both")
+	flag.IntVarP(&decibelStepFlag, "db-steps", "b", viper.GetInt("db-steps"), "set decibel increments")
+	flag.IntVarP(&fadeTimeFlag, "fade-time", "F", viper.GetInt("fade-time"), "set seconds of shift+digit volume fades")
+	flag.IntVarP(&sleepTimerFlag, "sleep-timer", "z", viper.GetInt("sleep-timer"), "set minutes before the sleep timer mutes")
 }
 func validateFlags() {
 	if setWidthFlag < minConfigWidth {
@@ -178,6 +294,36 @@ func validateFlags() {
 	if displayFlag > maxConfigDisplay {
 		displayFlag = viper.GetInt("device-display")
 	}
+	if meterRateFlag < minConfigRate {
+		meterRateFlag = viper.GetInt("meter-rate")
+	}
+	if meterRateFlag > maxConfigRate {
+		meterRateFlag = viper.GetInt("meter-rate")
+	}
+	if testSignalFlag != testSine && testSignalFlag != testNoise {
+		testSignalFlag = viper.GetString("test-signal")
+	}
+	if testStepFlag < minConfigStep {
+		testStepFlag = viper.GetInt("test-step")
+	}
+	if testStepFlag > maxConfigStep {
+		testStepFlag = viper.GetInt("test-step")
+	}
+	if !isNotifyMode(notifyFlag) {
+		notifyFlag = viper.GetString("notify")
+	}
+	if !isVolumeFormat(volumeFormatFlag) {
+		volumeFormatFlag = viper.GetString("volume-format")
+	}
+	if decibelStepFlag < minConfigDecibel || decibelStepFlag > maxConfigDecibel {
+		decibelStepFlag = viper.GetInt("db-steps")
+	}
+	if fadeTimeFlag < minConfigFade || fadeTimeFlag > maxConfigFade {
+		fadeTimeFlag = viper.GetInt("fade-time")
+	}
+	if sleepTimerFlag < minConfigSleep || sleepTimerFlag > maxConfigSleep {
+		sleepTimerFlag = viper.GetInt("sleep-timer")
+	}
 	// pass sane flag values to variables
 	setAltscreen = fullscreenFlag
 	setNoMessages = messagesFlag
@@ -186,9 +332,33 @@ func validateFlags() {
 	setWidth = setWidthFlag
 	setItems = setItemsFlag
 	setMaxVolume = float64(maxVolumeFlag)
+	setMaxVolumeArg = flag.CommandLine.Changed("max-volume")
 	setVolume = float64(setVolumeFlag)
 	setNoSymbol = symbolsFlag
 	setDisplay = displayFlag
+	setMeters = metersFlag
+	setMeterRate = meterRateFlag
+	setRecordDir = expandPath(recordDirFlag)
+	setTestSignal = testSignalFlag
+	setTestStep = testStepFlag
+	setHTTPAddr = httpAddrFlag
+	setMetricsAddr = metricsAddrFlag
+	setNotify = notifyFlag
+	setVolumeFormat = volumeFormatFlag
+	setDecibelStep = decibelStepFlag
+	setFadeTime = fadeTimeFlag
+	setSleepTimer = sleepTimerFlag
+}
+
+// expand environment variables and a leading ~ in a configured path
+func expandPath(p string) string {
+	p = os.ExpandEnv(p)
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
 }
 
 // load color values into program color variables
@@ -217,6 +387,10 @@ func loadConfigColors(c *Config, device, toggle *[]lipgloss.AdaptiveColor) {
 		c.Colors.Output.Light = green
 		c.Colors.Output.Dark = blue
 	}
+	if c.Colors.Warning.Light == "" && c.Colors.Warning.Dark == "" { // amplified volume
+		c.Colors.Warning.Light = red
+		c.Colors.Warning.Dark = Red
+	}
 	if setNoColor { // load defaults if config file is not loaded
 		c.Colors.Inactive.Light = ""
 		c.Colors.Inactive.Dark = ""
@@ -228,6 +402,8 @@ func loadConfigColors(c *Config, device, toggle *[]lipgloss.AdaptiveColor) {
 		c.Colors.Source.Dark = ""
 		c.Colors.Output.Light = ""
 		c.Colors.Output.Dark = ""
+		c.Colors.Warning.Light = ""
+		c.Colors.Warning.Dark = ""
 	}
 	*toggle = []lipgloss.AdaptiveColor{
 		{Light: c.Colors.Inactive.Light, Dark: c.Colors.Inactive.Dark}, // 0 unselected item
@@ -237,6 +413,68 @@ func loadConfigColors(c *Config, device, toggle *[]lipgloss.AdaptiveColor) {
 		{Light: c.Colors.Stream.Light, Dark: c.Colors.Stream.Dark}, // 2 stream
 		{Light: c.Colors.Source.Light, Dark: c.Colors.Source.Dark}, // 3 source
 		{Light: c.Colors.Output.Light, Dark: c.Colors.Output.Dark}} // 4 output
+	warningColor = lipgloss.AdaptiveColor{Light: c.Colors.Warning.Light, Dark: c.Colors.Warning.Dark}
+}
+
+// load hook commands, dropping unknown events and hooks left empty
+func loadConfigHooks(c *Config) {
+	if c.Hooks.Timeout < minConfigTimeout || c.Hooks.Timeout > maxConfigTimeout {
+		c.Hooks.Timeout = 5
+	}
+	if c.Hooks.BatteryLow < minConfigBattery || c.Hooks.BatteryLow > maxConfigBattery {
+		c.Hooks.BatteryLow = 20
+	}
+	setHookTimeout = c.Hooks.Timeout
+	setBatteryLow = c.Hooks.BatteryLow
+	setHooks = map[string]string{}
+	for _, e := range []string{event_added, event_removed, event_default, event_input,
+		event_started, event_ended, event_mute, event_battery} {
+		if command := c.Hooks.Commands[e]; command != "" {
+			setHooks[e] = command
+		}
+	}
+}
+
+// load volume locks, dropping locks without a device, mode or sane volume
+func loadConfigLocks(c *Config) {
+	setLocks = nil
+	for _, l := range c.Locks {
+		if l.Device == "" || (l.Mode != lockFixed && l.Mode != lockMax) {
+			continue
+		}
+		if l.Volume < 0 || l.Volume > maxConfigVolume {
+			continue
+		}
+		setLocks = append(setLocks, l)
+	}
+}
+
+// load per type and per device limits, unset or insane values inherit the global setting
+func loadConfigLimits(c *Config) {
+	sane := func(s VolumeSettings) VolumeSettings {
+		if s.VolumeLimit < minConfigVolume || s.VolumeLimit > maxConfigVolume {
+			s.VolumeLimit = 0
+		}
+		if s.VolumeSteps < minConfigIncrement || s.VolumeSteps > maxConfigIncrement {
+			s.VolumeSteps = 0
+		}
+		return s
+	}
+	setTypeLimits[pulsesink] = sane(c.Limits.Sink)
+	setTypeLimits[pulsestream] = sane(c.Limits.Stream)
+	setTypeLimits[pulsesource] = sane(c.Limits.Source)
+	setTypeLimits[pulseoutput] = sane(c.Limits.Output)
+	setDeviceLimits = nil
+	for _, d := range c.Limits.Devices {
+		pattern, err := regexp.Compile(d.Match)
+		if err != nil || d.Match == "" {
+			errorLoad = "invalid device limit pattern: " + d.Match
+			continue
+		}
+		d.pattern = pattern
+		d.VolumeSettings = sane(d.VolumeSettings)
+		setDeviceLimits = append(setDeviceLimits, d)
+	}
 }
 
 func loadConfigStyles(c *Config) lipgloss.Border {
@@ -258,17 +496,42 @@ func loadConfigStyles(c *Config) lipgloss.Border {
 
 type Config struct {
 	Settings struct {
-		Fullscreen    bool `mapstructure:"fullscreen"`
-		NoHelp        bool `mapstructure:"nohelp"`
-		NoMessage     bool `mapstructure:"nomessage"`
-		NoTitle       bool `mapstructure:"notitle"`
-		Width         int  `mapstructure:"width"`
-		Items         int  `mapstructure:"items"`
-		VolumeLimit   int  `mapstructure:"volumelimit"`
-		VolumeSteps   int  `mapstructure:"volumesteps"`
-		NoSymbols     bool `mapstructure:"nosymbols"`
-		DeviceDisplay int  `mapstructure:"devicedisplay"`
+		Fullscreen    bool   `mapstructure:"fullscreen"`
+		NoHelp        bool   `mapstructure:"nohelp"`
+		NoMessage     bool   `mapstructure:"nomessage"`
+		NoTitle       bool   `mapstructure:"notitle"`
+		Width         int    `mapstructure:"width"`
+		Items         int    `mapstructure:"items"`
+		VolumeLimit   int    `mapstructure:"volumelimit"`
+		VolumeSteps   int    `mapstructure:"volumesteps"`
+		NoSymbols     bool   `mapstructure:"nosymbols"`
+		DeviceDisplay int    `mapstructure:"devicedisplay"`
+		Meters        bool   `mapstructure:"meters"`
+		MeterRate     int    `mapstructure:"meterrate"`
+		RecordDir     string `mapstructure:"recorddir"`
+		TestSignal    string `mapstructure:"testsignal"`
+		TestStep      int    `mapstructure:"teststep"`
+		HTTPAddr      string `mapstructure:"httpaddr"`
+		MetricsAddr   string `mapstructure:"metricsaddr"`
+		Notify        string `mapstructure:"notify"`
+		VolumeFormat  string `mapstructure:"volumeformat"`
+		DecibelSteps  int    `mapstructure:"decibelsteps"`
+		FadeTime      int    `mapstructure:"fadetime"`
+		SleepTimer    int    `mapstructure:"sleeptimer"`
 	} `mapstructure:"settings"`
+	Hooks struct {
+		Timeout    int               `mapstructure:"timeout"`
+		BatteryLow int               `mapstructure:"batterylow"`
+		Commands   map[string]string `mapstructure:"commands"`
+	} `mapstructure:"hooks"`
+	Locks  []VolumeLock `mapstructure:"locks"`
+	Limits struct {
+		Sink    VolumeSettings `mapstructure:"sink"`
+		Stream  VolumeSettings `mapstructure:"stream"`
+		Source  VolumeSettings `mapstructure:"source"`
+		Output  VolumeSettings `mapstructure:"output"`
+		Devices []DeviceLimit  `mapstructure:"devices"`
+	} `mapstructure:"limits"`
 	Colors struct {
 		Inactive struct {
 			Light string `mapstructure:"light"`
@@ -294,6 +557,10 @@ type Config struct {
 			Light string `mapstructure:"light"`
 			Dark  string `mapstructure:"dark"`
 		} `mapstructure:"output"`
+		Warning struct {
+			Light string `mapstructure:"light"`
+			Dark  string `mapstructure:"dark"`
+		} `mapstructure:"warning"`
 	} `mapstructure:"colors"`
 	Styles struct {
 		Border int `mapstructure:"border"`
@@ -0,0 +1,289 @@
+// /////////////////////////////////////////////////////////////////////////////
+// LOCAL CONTROL SOCKET AND CTL CLIENT
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"bufio"                                  // read line delimited requests
+	"encoding/json"                          // encode requests and replies
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"net"                                    // unix socket
+	"os"                                     // locate runtime directory
+	"path/filepath"                          // build socket path
+	"syscall"                                // check owner of the fallback directory
+	"time"                                   // request timeouts
+)
+
+const (
+	controlSocket  = "pulsemanager.sock" // socket file name in runtime directory
+	controlTimeout = 2 * time.Second     // how long a client waits for Update
+	exitNoServer   = 5                   // ctl could not reach a running pulsemanager
+)
+
+// one line of the control protocol sent by a client
+type controlRequest struct {
+	Command string `json:"command"`          // focus, select, volume, mute, action, state
+	Device  string `json:"device,omitempty"` // index, name or description substring
+	Value   string `json:"value,omitempty"`  // volume: +N, -N or N
+}
+
+// one line of the control protocol sent back to a client
+type controlReply struct {
+	OK      bool          `json:"ok"`
+	Message string        `json:"message"`
+	State   *controlState `json:"state,omitempty"`
+}
+
+// model state reported by the state command
+type controlState struct {
+	Cursor   *DeviceJSON  `json:"cursor,omitempty"`
+	Selected *DeviceJSON  `json:"selected,omitempty"`
+	Channel  int          `json:"channel"`
+	Limit    float64      `json:"volume_limit"`
+	Latency  int          `json:"latency"`
+	Devices  []DeviceJSON `json:"devices"`
+}
+
+// request injected into bubbletea Update, answered on reply
+type ControlMsg struct {
+	request controlRequest
+	reply   chan controlReply
+}
+
+// location of the control socket for this user, in $XDG_RUNTIME_DIR or, when
+// that is unset, in a directory in the temp dir only this user can open
+func controlPath() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		return filepath.Join(dir, controlSocket), nil
+	}
+	dir = filepath.Join(os.TempDir(), fmt.Sprintf("pulsemanager-%v", os.Getuid()))
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || info.Mode().Perm() != 0700 || !ok || int(stat.Uid) != os.Getuid() {
+		return "", fmt.Errorf("%v is not a private directory", dir)
+	}
+	return filepath.Join(dir, controlSocket), nil
+}
+
+// listen on the control socket and forward requests to the program
+// returns a function that closes the socket, nil if another instance owns it
+func serveControl(p *tea.Program) func() {
+	path, err := controlPath()
+	if err != nil {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close() // another pulsemanager is answering
+		return nil
+	}
+	os.Remove(path) // stale socket from a crashed instance
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleControlConn(p, conn)
+		}
+	}()
+	return func() {
+		listener.Close()
+		os.Remove(path)
+	}
+}
+
+// answer every request on a client connection
+func handleControlConn(p *tea.Program, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req controlRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(controlReply{Message: "invalid request"})
+			continue
+		}
+		reply := make(chan controlReply, 1)
+		p.Send(ControlMsg{request: req, reply: reply})
+		select {
+		case r := <-reply:
+			encoder.Encode(r)
+		case <-time.After(controlTimeout):
+			encoder.Encode(controlReply{Message: "no reply from pulsemanager"})
+		}
+	}
+}
+
+// move the cursor to a device, scrolling the list as needed
+func focusDevice(m *model, pos int) {
+	resetChannelMode(m)
+	m.Cursor.pos = pos
+	scrollToCursor(m)
+	m.Message = fmt.Sprintf("focus: %v", m.Device[pos].pulsedescription)
+}
+
+// current model state for the state command
+func exportState(m *model) *controlState {
+	s := &controlState{Channel: m.ChannelMode, Limit: m.VolumeLimit, Latency: m.Latency}
+	for i, d := range m.Device[:m.Count.total-m.Count.cards] {
+		j := exportDevice(d)
+		s.Devices = append(s.Devices, j)
+		if i == m.Cursor.pos {
+			s.Cursor = &j
+		}
+		if d.pulsetype == m.Selected.devicetype && d.pulseindex == m.Selected.index {
+			s.Selected = &j
+		}
+	}
+	return s
+}
+
+// every device on the server, for devices the tab, tree or groups leave out
+func hiddenModel(m *model) *model {
+	h := *m
+	h.Device, h.Count = m.Server, m.ServerCount
+	h.Cursor.pos = 0
+	return &h
+}
+
+// find the device of a request in the list shown, then among all devices
+func controlTarget(m *model, query string) (*model, int, int) {
+	shown := *m
+	shown.Device = m.Device[:m.Count.total-m.Count.cards]
+	if pos, code := matchDevice(&shown, query); code == exitOK {
+		return m, pos, code
+	}
+	h := hiddenModel(m)
+	h.Device = h.Device[:h.Count.total-h.Count.cards]
+	pos, code := matchDevice(h, query)
+	h.Device = m.Server
+	return h, pos, code
+}
+
+// apply a control request to the model, called by Update
+func handleControl(m *model, msg ControlMsg) tea.Cmd {
+	req := msg.request
+	m.Err = nil
+	if req.Command == "state" {
+		msg.reply <- controlReply{OK: true, Message: m.Message, State: exportState(m)}
+		return nil
+	}
+	target := m // model the command acts on, every device when it is not shown
+	if req.Device != "" {
+		t, pos, code := controlTarget(m, req.Device)
+		if code == exitOK && t != m && req.Command == "focus" { // focus must show it
+			switchTab(m, tabAll)
+			t, pos, code = controlTarget(m, req.Device)
+			if code == exitOK && t != m {
+				msg.reply <- controlReply{Message: fmt.Sprintf("%q is folded away", req.Device)}
+				return nil
+			}
+		}
+		if code != exitOK {
+			msg.reply <- controlReply{Message: fmt.Sprintf("no single device matches %q", req.Device)}
+			return nil
+		}
+		target = t
+		focusDevice(target, pos)
+	} else if m.Count.total-m.Count.cards < 1 {
+		msg.reply <- controlReply{Message: "no devices"}
+		return nil
+	}
+	switch req.Command {
+	case "focus":
+		if req.Device == "" {
+			msg.reply <- controlReply{Message: "focus requires a device"}
+			return nil
+		}
+	case "select":
+		selectDevice(target)
+	case "mute":
+		toggleMute(target)
+	case "action":
+		forEachStream(target, performAction)
+	case "volume":
+		valid := true
+		forEachStream(target, func(m *model) { valid = applyVolume(m, req.Value) })
+		if !valid {
+			msg.reply <- controlReply{Message: "volume requires +N, -N or N"}
+			return nil
+		}
+	default:
+		msg.reply <- controlReply{Message: fmt.Sprintf("unknown command %q", req.Command)}
+		return nil
+	}
+	if target != m { // the list and cursor shown stay as they are
+		m.Message, m.Err, m.Selected = target.Message, target.Err, target.Selected
+	}
+	msg.reply <- controlReply{OK: m.Err == nil, Message: m.Message}
+	return updateDevices(*m)
+}
+
+// pulsemanager ctl <command> [device] [value]
+func ctlCommand(args []string) int {
+	usage := "ctl <focus|select|mute|action|volume|state> [device] [value]"
+	if len(args) < 1 {
+		return commandUsage(usage)
+	}
+	req := controlRequest{Command: args[0]}
+	rest := args[1:]
+	if req.Command == "volume" { // last argument is the volume, device is optional
+		if len(rest) < 1 {
+			return commandUsage(usage)
+		}
+		req.Value = rest[len(rest)-1]
+		rest = rest[:len(rest)-1]
+	}
+	if len(rest) > 1 {
+		return commandUsage(usage)
+	}
+	if len(rest) == 1 {
+		req.Device = rest[0]
+	}
+	path, err := controlPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitNoServer
+	}
+	conn, err := net.DialTimeout("unix", path, controlTimeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pulsemanager is not running")
+		return exitNoServer
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * controlTimeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return exitNoServer
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "no reply from pulsemanager")
+		return exitNoServer
+	}
+	var reply controlReply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return exitFailed
+	}
+	if req.Command == "state" {
+		fmt.Print(string(line))
+		return exitOK
+	}
+	if !reply.OK {
+		fmt.Fprintln(os.Stderr, reply.Message)
+		return exitFailed
+	}
+	fmt.Println(reply.Message)
+	return exitOK
+}
@@ -0,0 +1,150 @@
+// /////////////////////////////////////////////////////////////////////////////
+// DAEMON MODE, EVENTS AND AUTOMATION WITHOUT THE INTERFACE
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                // format and print text
+	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
+	"log"                                // write daemon log
+	"os"                                 // lock and log files
+	"os/signal"                          // stop on interrupt
+	"path/filepath"                      // build file paths
+	"strconv"                            // read pid from lock file
+	"strings"                            // trim lock file contents
+	"syscall"                            // lock file and signals
+	"time"                               // refresh interval
+)
+
+const (
+	daemonLock    = "pulsemanager-daemon.lock" // lock file name in runtime directory
+	daemonLogFile = "daemon.log"               // log file name in cache directory
+	exitRunning   = 6                          // another daemon holds the lock
+)
+
+// location of the daemon lock file for this user
+func daemonLockPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("pulsemanager-daemon-%v.lock", os.Getuid()))
+	}
+	return filepath.Join(dir, daemonLock)
+}
+
+// default location of the daemon log
+func daemonLogPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "pulsemanager", daemonLogFile)
+}
+
+// take the daemon lock and write our pid into it, nil if another daemon holds it
+// the lock is released by the kernel when the process exits
+func lockDaemon() *os.File {
+	f, err := os.OpenFile(daemonLockPath(), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return f
+}
+
+// pid of a running daemon, 0 if none holds the lock
+func daemonPid() int {
+	f, err := os.Open(daemonLockPath())
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err == nil {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN) // nobody holds it, stale file
+		return 0
+	}
+	b := make([]byte, 32)
+	n, _ := f.Read(b)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b[:n])))
+	if err != nil {
+		return -1 // locked but pid not written yet
+	}
+	return pid
+}
+
+// run hooks and notifications of events in the background, logging failures
+func daemonEvents(logger *log.Logger, events []AudioEvent) {
+	for _, e := range events {
+		logger.Printf("%v: %v %v (%v)", e.name, getDeviceType(e.device.pulsetype),
+			e.device.pulseindex, e.device.pulsedescription)
+		if _, ok := setHooks[e.name]; ok {
+			e := e
+			go func() {
+				if err := runHook(e); err != nil {
+					logger.Printf("%v hook failed: %v", e.name, err)
+				}
+			}()
+		}
+	}
+	for _, n := range pendingNotifications(events) {
+		n := n
+		go func() {
+			if err := sendNotification(n); err != nil {
+				logger.Printf("notification failed: %v", err)
+			}
+		}()
+	}
+}
+
+// pulsemanager daemon [--log path]
+func daemonCommand(args []string) int {
+	logPath := daemonLogPath()
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	fs.StringVarP(&logPath, "log", "l", logPath, "log file")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return commandUsage("daemon [--log path]")
+	}
+	lock := lockDaemon()
+	if lock == nil {
+		fmt.Fprintf(os.Stderr, "pulsemanager daemon is already running (pid %v)\n", daemonPid())
+		return exitRunning
+	}
+	defer lock.Close()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("started (pid %v)", os.Getpid())
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	d, _ := buildDevices(buildPulse())
+	def := readDefaults()
+	ticker := time.NewTicker(interval * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case s := <-stop:
+			logger.Printf("stopped by %v", s)
+			return exitOK
+		case <-ticker.C:
+			next, _ := buildDevices(buildPulse())
+			nextDef := readDefaults()
+			daemonEvents(logger, diffDevices(d, next, def, nextDef))
+			for _, fixed := range enforceLocks(setLocks, next) {
+				logger.Print(fixed)
+			}
+			d, def = next, nextDef
+		}
+	}
+}
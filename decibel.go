@@ -0,0 +1,100 @@
+// /////////////////////////////////////////////////////////////////////////////
+// DECIBEL DISPLAY AND STEPS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"  // format and print text
+	"math" // convert between percent and decibels
+)
+
+// how volume is labeled next to bars, config values
+const (
+	formatPercent = "percent" // 65%
+	formatDecibel = "db"      // -11.2 dB
+	formatBoth    = "both"    // 65% -11.2 dB
+)
+
+// check a configured volume format
+func isVolumeFormat(f string) bool {
+	return f == formatPercent || f == formatDecibel || f == formatBoth
+}
+
+// pulseaudio maps percent to decibels with a cubic curve
+func percentToDB(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	return 60 * math.Log10(p/100)
+}
+
+// label of one channel volume in the current format
+func volumeLabel(m *model, d PulseDevice, channel int) string {
+	percent := fmt.Sprintf("%3.0f%%", d.pulsevolume[channel])
+	db := "  -inf dB"
+	if channel < len(d.pulsedb) && !math.IsInf(d.pulsedb[channel], -1) {
+		db = fmt.Sprintf("%6.1f dB", d.pulsedb[channel])
+	}
+	switch m.VolumeFormat {
+	case formatDecibel:
+		return " " + db
+	case formatBoth:
+		return " " + percent + " " + db
+	}
+	return " " + percent
+}
+
+// cycle the volume label between percent, both and decibels
+func changeVolumeFormat(m *model) {
+	switch m.VolumeFormat {
+	case formatPercent:
+		m.VolumeFormat = formatBoth
+	case formatBoth:
+		m.VolumeFormat = formatDecibel
+	default:
+		m.VolumeFormat = formatPercent
+	}
+	m.Message = fmt.Sprintf("volume display: %v", m.VolumeFormat)
+}
+
+// switch h/l between percent and decibel steps
+func toggleDecibelSteps(m *model) {
+	m.DecibelSteps = !m.DecibelSteps
+	if m.DecibelSteps {
+		m.Message = fmt.Sprintf("volume steps: %v dB", setDecibelStep)
+		return
+	}
+	m.Message = fmt.Sprintf("volume steps: %v%%", volumeStep(m.Device[m.Cursor.pos]))
+}
+
+// prepare relative decibel changes for single or all channels, respecting the limit
+// pactl multiplies volumes for dB changes, so +3dB is always 3 dB louder
+func formatDecibelStep(m *model, inc bool) []string {
+	d := m.Device[m.Cursor.pos]
+	limit := percentToDB(volumeLimit(m, d))
+	var vol []string
+	for i := range d.pulsevolume {
+		if m.ChannelMode >= 0 && m.ChannelMode != i { // no changes for excluded channels
+			vol = append(vol, "+0dB")
+			continue
+		}
+		if !inc {
+			vol = append(vol, fmt.Sprintf("-%vdB", setDecibelStep))
+			continue
+		}
+		step := clampStep(float64(setDecibelStep), percentToDB(linkedVolume(m, d, i)), limit)
+		vol = append(vol, fmt.Sprintf("+%.2fdB", step))
+	}
+	return vol
+}
+
+// volume change strings for h/l in the current step mode
+func formatKeyVolume(m *model, inc bool) []string {
+	if m.Balance != balanceOff {
+		return formatBalanceStep(m, inc)
+	}
+	if m.DecibelSteps {
+		return formatDecibelStep(m, inc)
+	}
+	return formatDeviceVolume(m, inc)
+}
@@ -18,8 +18,10 @@ func updateDevices(m model) tea.Cmd {
 	w := m.StringLen
 	d, c := buildDevices(buildPulse())               // devices and device count
 	formatProgressBars(d, colorBars(deviceColor), w) // populate/style progress bars of devices
+	s := currentDefaults()                           // default devices when events are watched
+	p := daemonPid()                                 // a daemon runs hooks instead of us
 	return func() tea.Msg {                          // new PulseDevice structs
-		return RefreshMsg{d, c} // and count on every update
+		return RefreshMsg{d, c, s, p} // and count on every update
 	}
 }
 
@@ -87,6 +89,11 @@ func formatProgressBars(device []PulseDevice, colors []string, width int) {
 // //////////////////////////////////////////////////////////////////////////////
 // mute/unmute a device
 func toggleDeviceMute(m *model) {
+	setDeviceMute(m, toggle)
+}
+
+// set the mute of a device to 1, 0 or toggle
+func setDeviceMute(m *model, state string) {
 	var c, r string
 	var d int
 	switch m.Device[m.Cursor.pos].pulsetype {
@@ -107,10 +114,19 @@ func toggleDeviceMute(m *model) {
 	}
 	num := m.Device[m.Cursor.pos].pulseindex
 	index := strconv.Itoa(num)
-	cmd := exec.Command(pactl, c, index, toggle)
+	cmd := exec.Command(pactl, c, index, state)
 	err := cmd.Run()
 	if err != nil {
 		m.Message = fmt.Sprintf("error toggling device mute")
+		m.Err = err
+		return
+	}
+	if state != toggle { // the new mute is known
+		m.Message = fmt.Sprintf("unmuted: %v", m.Device[m.Cursor.pos].pulsedescription)
+		if state == "1" {
+			m.Message = fmt.Sprintf("muted: %v", m.Device[m.Cursor.pos].pulsedescription)
+		}
+		return
 	}
 	if d == pulsestream || d == pulseoutput { // no get-mute command for streams/outputs
 		m.Message = fmt.Sprintf("mute toggled: %v", m.Device[m.Cursor.pos].pulsedescription)
@@ -119,7 +135,7 @@ func toggleDeviceMute(m *model) {
 	out, err := exec.Command(pactl, r, index).Output()
 	if err != nil {
 		m.Message = fmt.Sprintf("error retrieving device mute")
-		fmt.Println("error")
+		m.Err = err
 	}
 	if strings.Contains(string(out), "yes") {
 		m.Message = fmt.Sprintf("muted: %v", m.Device[m.Cursor.pos].pulsedescription)
@@ -143,6 +159,8 @@ func killStreamOrOutput(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = fmt.Sprintf("error killing stream: %v", m.Device[m.Cursor.pos].pulsename)
+		m.Err = err
+		return
 	}
 	m.Message = fmt.Sprintf("killed stream: %v", m.Device[m.Cursor.pos].pulsename)
 }
@@ -162,6 +180,7 @@ func changeDefaultSink(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error changing default sink"
+		m.Err = err
 		return
 	}
 	moveAllStreams(m, getStreamIndexes(m), index) // get streams, move each stream to new default sink
@@ -184,6 +203,7 @@ func changeDefaultSource(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error changing default source"
+		m.Err = err
 		return
 	}
 	m.Message = fmt.Sprintf("changed default source to: %v", m.Device[m.Cursor.pos].pulsename)
@@ -199,6 +219,7 @@ func moveAllStreams(m *model, stream []int, sink string) {
 		err := cmd.Run()
 		if err != nil {
 			m.Message = "error moving streams"
+			m.Err = err
 		}
 	}
 }
@@ -206,7 +227,7 @@ func moveAllStreams(m *model, stream []int, sink string) {
 // assemble a slice containing each stream (called by moveAllStreams() )
 func getStreamIndexes(m *model) []int {
 	var index []int
-	for _, v := range m.Device {
+	for _, v := range m.Server {
 		if v.pulsetype == pulsestream {
 			index = append(index, v.pulseindex)
 		}
@@ -239,12 +260,18 @@ func changeDeviceVolume(m *model, vol []string) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error changing device volume"
+		m.Err = err
 	}
 }
 
 // prepare volume change strings for single or all channels, skip max volume requests
 // takes bool for inc/dec (called by changeDeviceVolume())
 func formatDeviceVolume(m *model, inc bool) []string {
+	return formatVolumeStep(m, inc, volumeStep(m.Device[m.Cursor.pos]))
+}
+
+// prepare volume change strings using a specific step percentage
+func formatVolumeStep(m *model, inc bool, step float64) []string {
 	var limit bool
 	var prefix string
 	if inc {
@@ -256,21 +283,22 @@ func formatDeviceVolume(m *model, inc bool) []string {
 	}
 	var entry string
 	var vol []string
+	max := volumeLimit(m, m.Device[m.Cursor.pos])
 	for i := 0; i < len(m.Device[m.Cursor.pos].pulsevolume); i++ {
 		if m.ChannelMode < 0 { // all channels
-			if limit == true && m.Device[m.Cursor.pos].pulsevolume[i] >= m.VolumeLimit {
-				entry = fmt.Sprintf("%v0%%", prefix)
+			if limit == true {
+				entry = fmt.Sprintf("%v%v%%", prefix, clampStep(step, linkedVolume(m, m.Device[m.Cursor.pos], i), max))
 				vol = append(vol, entry)
 				continue
 			}
-			entry = fmt.Sprintf("%v%v%%", prefix, setVolume)
+			entry = fmt.Sprintf("%v%v%%", prefix, step)
 		} else if m.ChannelMode == i { // specific channels
-			if limit == true && m.Device[m.Cursor.pos].pulsevolume[i] >= m.VolumeLimit {
-				entry = fmt.Sprintf("%v0%%", prefix)
+			if limit == true {
+				entry = fmt.Sprintf("%v%v%%", prefix, clampStep(step, m.Device[m.Cursor.pos].pulsevolume[i], max))
 				vol = append(vol, entry)
 				continue
 			}
-			entry = fmt.Sprintf("%v%v%%", prefix, setVolume)
+			entry = fmt.Sprintf("%v%v%%", prefix, step)
 		} else { // no changes for excluded channels
 			entry = fmt.Sprintf("%v0%%", prefix)
 		}
@@ -281,6 +309,9 @@ func formatDeviceVolume(m *model, inc bool) []string {
 
 // set volume for all channels on target device from 10%-100%
 func normalizeDeviceVolume(m *model, v int) {
+	if max := volumeLimit(m, m.Device[m.Cursor.pos]); float64(v) > max { // never set a preset past the device volume limit
+		v = int(max)
+	}
 	var args []string
 	var p string
 	switch m.Device[m.Cursor.pos].pulsetype {
@@ -297,12 +328,14 @@ func normalizeDeviceVolume(m *model, v int) {
 	num := m.Device[m.Cursor.pos].pulseindex
 	target := strconv.Itoa(num)
 	args = append(args, target)
-	vol := fmt.Sprintf("%v%%", v)
-	args = append(args, vol)
+	m.ChannelMode = -1 // presets move every channel
+	args = append(args, channelTargets(m, m.Device[m.Cursor.pos], float64(v))...)
 	cmd := exec.Command(pactl, args...)
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error normalize volume"
+		m.Err = err
+		return
 	}
 	m.ChannelMode = -1 // return to controlling all channels
 	m.Message = fmt.Sprintf("volume set to %v%%", v)
@@ -324,6 +357,7 @@ func moveStreamToSink(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error migrating stream"
+		m.Err = err
 		return
 	}
 	m.Message = fmt.Sprintf("stream: #%v sent to sink: #%v", stream, sink)
@@ -346,6 +380,7 @@ func moveOutputToSource(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error migrating output"
+		m.Err = err
 		return
 	}
 	m.Message = fmt.Sprintf("output: #%v sent to source: #%v", output, source)
@@ -364,6 +399,8 @@ func killLoopback(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = fmt.Sprintf("error unloading module: #%v %v", module, name)
+		m.Err = err
+		return
 	}
 	m.Message = fmt.Sprintf("killed %v", name)
 }
@@ -374,6 +411,8 @@ func unloadLoopback(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error unloading loopback module"
+		m.Err = err
+		return
 	}
 	m.Message = "killed all loopback streams"
 	resetSelected(m) // unset Selected after operation
@@ -394,12 +433,13 @@ func loopbackSourceToSink(m *model) {
 	source = fmt.Sprintf("source=%v", source)
 	sink := strconv.Itoa(m.Selected.index)
 	sink = fmt.Sprintf("sink=%v", sink)
-	latency := strconv.Itoa(varLatency)
+	latency := strconv.Itoa(m.Latency)
 	latency = fmt.Sprintf("latency_msec=%v", latency)
 	cmd := exec.Command(pactl, load_module, loopback_module, latency, sink, source)
 	err := cmd.Run()
 	if err != nil {
 		m.Message = "error executing source loopback"
+		m.Err = err
 		return
 	}
 	m.Message = fmt.Sprintf("source: #%v sent to sink: #%v", source, sink)
@@ -409,17 +449,17 @@ func loopbackSourceToSink(m *model) {
 // increment or decrement latency value to be used with pactl commands
 func changeLatency(m *model, inc bool) {
 	if inc {
-		varLatency += incLatency
-		if varLatency > maxLatency {
-			varLatency = minLatency
+		m.Latency += incLatency
+		if m.Latency > maxLatency {
+			m.Latency = minLatency
 		}
 	} else {
-		varLatency -= incLatency
-		if varLatency < minLatency {
-			varLatency = maxLatency
+		m.Latency -= incLatency
+		if m.Latency < minLatency {
+			m.Latency = maxLatency
 		}
 	}
-	m.Message = fmt.Sprintf("latency set to: %v milliseconds", varLatency)
+	m.Message = fmt.Sprintf("latency set to: %v milliseconds", m.Latency)
 }
 
 // select the device to subsequently perform an action
@@ -457,6 +497,8 @@ func suspendSinkOrSource(m *model) {
 	err := cmd.Run()
 	if err != nil {
 		m.Message = fmt.Sprintf("error suspending device")
+		m.Err = err
+		return
 	}
 	m.Message = fmt.Sprintf("suspend: %v %v", state, m.Device[m.Cursor.pos].pulsedescription)
 	resetSelected(m)
@@ -0,0 +1,111 @@
+// /////////////////////////////////////////////////////////////////////////////
+// AUDIO EVENTS DETECTED BETWEEN REFRESHES
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+// names of events, used as keys in the configuration file
+const (
+	event_added   = "device-added"   // sink or source appeared
+	event_removed = "device-removed" // sink or source disappeared
+	event_default = "default-sink"   // default sink changed
+	event_input   = "default-source" // default source changed
+	event_started = "stream-started" // stream or output appeared
+	event_ended   = "stream-ended"   // stream or output disappeared
+	event_mute    = "mute"           // mute state of any device changed
+	event_battery = "battery-low"    // battery fell below the configured level
+)
+
+// something that changed between two device lists
+type AudioEvent struct {
+	name   string      // one of the event_* constants
+	device PulseDevice // device the event is about
+}
+
+// is any feature waiting for events, avoids extra pactl calls on refresh
+func watchingEvents() bool {
+	return len(setHooks) > 0 || setNotify != notifyOff
+}
+
+// names of the default devices, empty when nothing is watching events
+func currentDefaults() Defaults {
+	if !watchingEvents() {
+		return Defaults{}
+	}
+	return readDefaults()
+}
+
+// names of the default devices from pactl
+func readDefaults() Defaults {
+	return Defaults{getDefaultDevice(default_sink_rpl), getDefaultDevice(default_source_rpl)}
+}
+
+// compare two refreshes and list what changed
+func diffDevices(old, new []PulseDevice, oldDefault, newDefault Defaults) []AudioEvent {
+	var events []AudioEvent
+	before := map[string]PulseDevice{}
+	for _, d := range old {
+		before[meterKey(d)] = d
+	}
+	after := map[string]bool{}
+	for _, d := range new {
+		if d.pulsetype == pulsecard {
+			continue
+		}
+		after[meterKey(d)] = true
+		prev, ok := before[meterKey(d)]
+		if !ok {
+			events = append(events, AudioEvent{addedEvent(d), d})
+			if isBatteryLow(d.pulsebattery) {
+				events = append(events, AudioEvent{event_battery, d})
+			}
+			continue
+		}
+		if prev.pulsemute != d.pulsemute {
+			events = append(events, AudioEvent{event_mute, d})
+		}
+		if isBatteryLow(d.pulsebattery) && !isBatteryLow(prev.pulsebattery) {
+			events = append(events, AudioEvent{event_battery, d})
+		}
+	}
+	for _, d := range old {
+		if d.pulsetype != pulsecard && !after[meterKey(d)] {
+			events = append(events, AudioEvent{removedEvent(d), d})
+		}
+	}
+	events = append(events, defaultEvent(new, event_default, pulsesink, oldDefault.sink, newDefault.sink)...)
+	events = append(events, defaultEvent(new, event_input, pulsesource, oldDefault.source, newDefault.source)...)
+	return events
+}
+
+// event for a changed default device, none if it did not change or is unknown
+func defaultEvent(d []PulseDevice, name string, kind int, old, new string) []AudioEvent {
+	if old == "" || new == "" || old == new {
+		return nil
+	}
+	for _, v := range d {
+		if v.pulsetype == kind && v.pulsename == new {
+			return []AudioEvent{{name, v}}
+		}
+	}
+	return nil
+}
+
+// sinks and sources are devices, streams and outputs come and go as streams
+func addedEvent(d PulseDevice) string {
+	if d.pulsetype == pulsestream || d.pulsetype == pulseoutput {
+		return event_started
+	}
+	return event_added
+}
+func removedEvent(d PulseDevice) string {
+	if d.pulsetype == pulsestream || d.pulsetype == pulseoutput {
+		return event_ended
+	}
+	return event_removed
+}
+
+// is a battery property below the configured warning level
+func isBatteryLow(battery string) bool {
+	level, ok := batteryPercent(battery)
+	return ok && level < float64(setBatteryLow)
+}
@@ -0,0 +1,231 @@
+// /////////////////////////////////////////////////////////////////////////////
+// VOLUME FADES AND SLEEP TIMER
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"math"                                   // round fade steps
+	"os/exec"                                // run external system commands
+	"strconv"                                // convert types to/from string
+	"strings"                                // map fade keys to levels
+	"time"                                   // fade steps and sleep timer
+)
+
+const (
+	fadeFrame = 100 * time.Millisecond // time between volume steps of a fade
+	fadeKeys  = "!@#$%^&*()"           // shift+1..0 fade to 10%..100%
+	sleepFade = time.Minute            // length of the fade when the sleep timer fires
+)
+
+// state of a running fade
+type Fade struct {
+	active   bool
+	kind     int           // pulsetype of faded device
+	index    int           // pulseindex of faded device
+	name     string        // description for messages
+	mute     string        // pactl mute command for the device type
+	from     []float64     // channel volumes when the fade started
+	to       float64       // target volume of every channel
+	start    time.Time     // when the fade started
+	duration time.Duration // how long the fade takes
+	sleep    bool          // mute and restore the volume at the end
+	id       int           // ignore FadeMsg from a previous fade
+}
+
+// state of the sleep timer
+type Sleep struct {
+	active bool
+	at     time.Time // when the fade out starts
+	id     int       // ignore SleepMsg from a cancelled timer
+}
+
+type FadeMsg struct{ id int }  // advance a fade by one step
+type SleepMsg struct{ id int } // sleep timer ran out
+
+func fadeCmd(id int) tea.Cmd {
+	return tea.Tick(fadeFrame, func(t time.Time) tea.Msg {
+		return FadeMsg{id}
+	})
+}
+
+func sleepCmd(id int, d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return SleepMsg{id}
+	})
+}
+
+var fadeCount = 0 // number of fades and sleep timers started
+
+// volume a fade key leads to, 0 if the key is not a fade key
+func fadeLevel(k string) int {
+	if len(k) != 1 {
+		return 0
+	}
+	if i := strings.Index(fadeKeys, k); i >= 0 {
+		return (i + 1) * 10
+	}
+	return 0
+}
+
+// pactl mute command for a device type
+func muteCmd(t int) string {
+	switch t {
+	case pulsestream:
+		return stream_mute_cmd
+	case pulsesource:
+		return source_mute_cmd
+	case pulseoutput:
+		return output_mute_cmd
+	}
+	return sink_mute_cmd
+}
+
+// start fading a device to a volume, replacing any running fade
+func startFade(m *model, d PulseDevice, to float64, duration time.Duration, sleep bool) tea.Cmd {
+	fadeCount++
+	m.Fade = Fade{
+		active:   true,
+		kind:     d.pulsetype,
+		index:    d.pulseindex,
+		name:     d.pulsedescription,
+		mute:     muteCmd(d.pulsetype),
+		from:     append([]float64(nil), d.pulsevolume...),
+		to:       to,
+		start:    time.Now(),
+		duration: duration,
+		sleep:    sleep,
+		id:       fadeCount,
+	}
+	m.ChannelMode = -1 // fades move every channel
+	m.Message = fmt.Sprintf("fading to %v%% over %.0fs: %v", to, duration.Seconds(), d.pulsedescription)
+	return fadeCmd(fadeCount)
+}
+
+// fade the device on cursor to the level of a shift+digit key
+func fadeDevice(m *model, k string) tea.Cmd {
+	d := m.Device[m.Cursor.pos]
+	if isGroup(d) {
+		m.Message = "fades move single streams"
+		return nil
+	}
+	v := float64(fadeLevel(k))
+	if max := volumeLimit(m, d); v > max {
+		v = max
+	}
+	return startFade(m, d, v, time.Duration(setFadeTime)*time.Second, false)
+}
+
+// set the faded device to the volume for the elapsed time, finishing at the target
+func stepFade(m *model, msg FadeMsg) tea.Cmd {
+	f := &m.Fade
+	if !f.active || msg.id != f.id {
+		return nil
+	}
+	p := float64(time.Since(f.start)) / float64(f.duration)
+	if p > 1 {
+		p = 1
+	}
+	args := []string{volumeCmd(f.kind), strconv.Itoa(f.index)}
+	vol := make([]float64, len(f.from))
+	for i, v := range f.from {
+		vol[i] = math.Round(v + (f.to-v)*p)
+		args = append(args, fmt.Sprintf("%v%%", vol[i]))
+	}
+	if err := exec.Command(pactl, args...).Run(); err != nil {
+		f.active = false
+		m.Message = fmt.Sprintf("fade stopped: %v", f.name)
+		m.Err = err
+		return nil
+	}
+	if p < 1 { // show the step without a full refresh
+		for i, d := range m.Device {
+			if d.pulsetype == f.kind && d.pulseindex == f.index && len(d.pulsevolume) == len(vol) {
+				m.Device[i].pulsevolume = vol
+				for j := range vol {
+					if j < len(d.pulsedb) {
+						m.Device[i].pulsedb[j] = percentToDB(vol[j])
+					}
+				}
+			}
+		}
+		return fadeCmd(f.id)
+	}
+	f.active = false
+	m.Message = fmt.Sprintf("faded to %v%%: %v", f.to, f.name)
+	if f.sleep { // mute, then put the volume back for the next listener
+		m.Sleep.active = false
+		index := strconv.Itoa(f.index)
+		restore := []string{volumeCmd(f.kind), index}
+		for _, v := range f.from {
+			restore = append(restore, fmt.Sprintf("%v%%", v))
+		}
+		if exec.Command(pactl, f.mute, index, "1").Run() != nil || exec.Command(pactl, restore...).Run() != nil {
+			m.Message = fmt.Sprintf("error muting after sleep: %v", f.name)
+			return updateDevices(*m)
+		}
+		m.Message = fmt.Sprintf("sleep timer muted: %v", f.name)
+	}
+	return updateDevices(*m)
+}
+
+// stop a running fade where it is, called before other volume changes
+func cancelFade(m *model) {
+	if !m.Fade.active {
+		return
+	}
+	m.Fade.active = false
+	if m.Fade.sleep {
+		m.Sleep.active = false
+	}
+	m.Message = fmt.Sprintf("fade cancelled: %v", m.Fade.name)
+}
+
+// start or cancel the sleep timer
+func toggleSleep(m *model) tea.Cmd {
+	if m.Sleep.active {
+		m.Sleep.active = false
+		if m.Fade.active && m.Fade.sleep {
+			m.Fade.active = false
+		}
+		m.Message = "sleep timer off"
+		return nil
+	}
+	fadeCount++
+	d := time.Duration(setSleepTimer) * time.Minute
+	m.Sleep = Sleep{active: true, at: time.Now().Add(d), id: fadeCount}
+	m.Message = fmt.Sprintf("sleep timer: default sink mutes in %vm", setSleepTimer)
+	return sleepCmd(fadeCount, d)
+}
+
+// fade the default sink out once the sleep timer runs out
+func startSleepFade(m *model, msg SleepMsg) tea.Cmd {
+	if !m.Sleep.active || msg.id != m.Sleep.id {
+		return nil
+	}
+	name := readDefaults().sink
+	for _, d := range m.Server {
+		if d.pulsetype == pulsesink && d.pulsename == name {
+			return startFade(m, d, 0, sleepFade, true)
+		}
+	}
+	m.Sleep.active = false
+	m.Message = "sleep timer: no default sink"
+	return nil
+}
+
+// remaining sleep time shown in the title
+func displaySleep(m *model) string {
+	if !m.Sleep.active {
+		return ""
+	}
+	if m.Fade.active && m.Fade.sleep {
+		return " · sleeping"
+	}
+	left := time.Until(m.Sleep.at).Round(time.Minute)
+	if left < time.Minute {
+		return " · sleep <1m"
+	}
+	return fmt.Sprintf(" · sleep %vm", int(left.Minutes()))
+}
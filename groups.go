@@ -0,0 +1,217 @@
+// /////////////////////////////////////////////////////////////////////////////
+// STREAM GROUPS BY APPLICATION
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"     // format and print text
+	"strings" // list member indexes
+)
+
+// check whether a device is the header of a stream group
+func isGroup(d PulseDevice) bool {
+	return len(d.pulsemembers) > 0
+}
+
+// application a stream is grouped by, empty for streams that are never grouped
+func groupKey(d PulseDevice) string {
+	if d.pulsetype != pulsestream {
+		return ""
+	}
+	if d.pulseapp != "" {
+		return d.pulseapp
+	}
+	return d.pulsename // process binary
+}
+
+// group a stream belongs to, in tree view split by the sink it plays on so
+// every stream stays under the sink it lands on
+func groupID(m *model, d PulseDevice) string {
+	k := groupKey(d)
+	if m.Tree && k != "" {
+		return fmt.Sprintf("%v@%v", k, d.pulsesinkindex)
+	}
+	return k
+}
+
+// header standing in for the streams of one application
+// volume and channels follow the loudest member, mute needs every member muted
+func groupHeader(m *model, key string, members []PulseDevice) PulseDevice {
+	first := members[0]
+	h := PulseDevice{
+		pulsetype:        pulsestream,
+		pulseindex:       -1 - first.pulseindex, // unique, never sent to pactl
+		pulsesinkindex:   first.pulsesinkindex,
+		pulsename:        key,
+		pulsedescription: fmt.Sprintf("%v (%v streams)", key, len(members)),
+		pulsecount:       first.pulsecount,
+		pulsechannels:    first.pulsechannels,
+		pulsevolume:      append([]float64(nil), first.pulsevolume...),
+		pulsedb:          append([]float64(nil), first.pulsedb...),
+		pulsemute:        true,
+		pulsemembers:     members,
+	}
+	for _, s := range members {
+		h.pulsemute = h.pulsemute && s.pulsemute
+		if s.pulsesinkindex != h.pulsesinkindex {
+			h.pulsesinkindex = -1 // members play on different sinks
+		}
+		if s.pulsecount != h.pulsecount {
+			continue
+		}
+		for i, v := range s.pulsevolume {
+			if v > h.pulsevolume[i] {
+				h.pulsevolume[i] = v
+				if i < len(s.pulsedb) && i < len(h.pulsedb) {
+					h.pulsedb[i] = s.pulsedb[i]
+				}
+			}
+		}
+	}
+	header := []PulseDevice{h}
+	formatProgressBars(header, colorBars(deviceColor), m.StringLen)
+	return header[0]
+}
+
+// replace streams of applications with several streams by a header,
+// followed by the streams themselves unless the group is collapsed
+func groupStreams(m *model, d []PulseDevice, c DeviceCount) ([]PulseDevice, DeviceCount) {
+	if !m.Grouping {
+		return d, c
+	}
+	members := map[string][]PulseDevice{}
+	for _, v := range d {
+		if k := groupID(m, v); k != "" {
+			members[k] = append(members[k], v)
+		}
+	}
+	var grouped []PulseDevice
+	done := map[string]bool{}
+	for _, v := range d {
+		k := groupID(m, v)
+		if k == "" || len(members[k]) < 2 {
+			grouped = append(grouped, v)
+			continue
+		}
+		if done[k] { // members follow the header of their group
+			continue
+		}
+		done[k] = true
+		grouped = append(grouped, groupHeader(m, groupKey(v), members[k]))
+		if !m.Collapsed[k] {
+			grouped = append(grouped, members[k]...)
+		}
+	}
+	c.streams += len(grouped) - len(d)
+	c.total = len(grouped)
+	return grouped, c
+}
+
+// turn grouping of streams by application on or off
+func toggleGrouping(m *model) {
+	key := meterKey(m.Device[m.Cursor.pos])
+	m.Grouping = !m.Grouping
+	arrangeDevices(m)
+	followCursor(m, key)
+	if m.Grouping {
+		m.Message = "streams grouped by application"
+		return
+	}
+	m.Message = "streams ungrouped"
+}
+
+// collapse or expand the group, or tree branch, on cursor
+func toggleCollapse(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if m.Tree && isBranch(d) {
+		toggleBranch(m)
+		return
+	}
+	if !isGroup(d) {
+		m.Message = "not a stream group"
+		return
+	}
+	id := groupID(m, d)
+	m.Collapsed[id] = !m.Collapsed[id]
+	if !m.Collapsed[id] {
+		delete(m.Collapsed, id)
+	}
+	arrangeDevices(m)
+	m.Message = fmt.Sprintf("%v expanded", d.pulsename)
+	if m.Collapsed[id] {
+		m.Message = fmt.Sprintf("%v collapsed", d.pulsename)
+	}
+}
+
+// run a device action on every member of the group on cursor, or on the device
+func forEachStream(m *model, action func(m *model)) {
+	d := m.Device[m.Cursor.pos]
+	if !isGroup(d) {
+		action(m)
+		return
+	}
+	selected := m.Selected // moves unset the selection after the first stream
+	for _, s := range d.pulsemembers {
+		m.Device[m.Cursor.pos] = s
+		m.Selected = selected
+		action(m)
+	}
+	m.Device[m.Cursor.pos] = d
+	m.Message = fmt.Sprintf("%v streams: %v", len(d.pulsemembers), m.Message)
+}
+
+// mute the device on cursor, or every member of a group unless all of them
+// already are, then unmute them all, so a group never stays half muted
+func toggleMute(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if !isGroup(d) {
+		toggleDeviceMute(m)
+		return
+	}
+	state := "1"
+	if d.pulsemute { // every member is muted
+		state = "0"
+	}
+	forEachStream(m, func(m *model) { setDeviceMute(m, state) })
+}
+
+// arrow shown in front of group headers
+func displayGroup(m *model, d PulseDevice) string {
+	if !isGroup(d) {
+		return ""
+	}
+	if m.Collapsed[groupID(m, d)] {
+		return group_closed_icon
+	}
+	return group_open_icon
+}
+
+// stream index, or the indexes of every member of a group
+func displayStreamIndex(d PulseDevice) string {
+	if !isGroup(d) {
+		return fmt.Sprintf("#%v", d.pulseindex)
+	}
+	var list []string
+	for _, s := range d.pulsemembers {
+		list = append(list, fmt.Sprintf("#%v", s.pulseindex))
+	}
+	return strings.Join(list, " ")
+}
+
+// set every member of the group on cursor, or the device, to a preset volume
+func presetVolume(m *model, v int) {
+	forEachStream(m, func(m *model) { normalizeDeviceVolume(m, v) })
+}
+
+// indent members of an expanded group under their header
+func displayMember(m *model, d PulseDevice) string {
+	if !m.Grouping || d.pulsetype != pulsestream || isGroup(d) {
+		return ""
+	}
+	for _, v := range m.Device {
+		if isGroup(v) && groupKey(v.pulsemembers[0]) == groupKey(d) {
+			return member_icon
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+// /////////////////////////////////////////////////////////////////////////////
+// USER HOOK SCRIPTS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"context"                                // stop hooks that run too long
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"os"                                     // pass environment to hooks
+	"os/exec"                                // run external system commands
+	"strconv"                                // convert types to/from string
+	"time"                                   // hook timeout
+)
+
+type HookMsg struct { // result of a hook, reported in the message line
+	event string
+	err   error
+}
+
+// environment describing the device of an event
+func hookEnv(e AudioEvent) []string {
+	d := e.device
+	mute := "0"
+	if d.pulsemute {
+		mute = "1"
+	}
+	return append(os.Environ(),
+		"PM_EVENT="+e.name,
+		"PM_TYPE="+getDeviceType(d.pulsetype),
+		"PM_INDEX="+strconv.Itoa(d.pulseindex),
+		"PM_NAME="+d.pulsename,
+		"PM_DESCRIPTION="+d.pulsedescription,
+		"PM_STATE="+d.pulsestate,
+		"PM_MUTE="+mute,
+		"PM_VOLUME="+strconv.Itoa(deviceVolume(d)),
+		"PM_BATTERY="+d.pulsebattery,
+	)
+}
+
+// run the hook configured for an event, returns after it exits or times out
+func runHook(e AudioEvent) error {
+	command, ok := setHooks[e.name]
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(setHookTimeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = hookEnv(e)
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %vs", setHookTimeout)
+	}
+	return err
+}
+
+// run the hooks of all events outside of Update
+func hookCmds(events []AudioEvent) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, e := range events {
+		if _, ok := setHooks[e.name]; !ok {
+			continue
+		}
+		e := e
+		cmds = append(cmds, func() tea.Msg {
+			return HookMsg{e.name, runHook(e)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// show a failed hook in the message line
+func reportHook(m *model, msg HookMsg) {
+	if msg.err != nil {
+		m.Message = fmt.Sprintf("%v hook failed: %v", msg.event, msg.err)
+	}
+}
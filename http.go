@@ -0,0 +1,362 @@
+// /////////////////////////////////////////////////////////////////////////////
+// LOCAL HTTP/JSON API
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/json"                      // encode devices and replies
+	"errors"                             // tell an empty body from a bad one
+	"fmt"                                // format and print text
+	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
+	"io"                                 // detect an empty request body
+	"mime"                               // check the request content type
+	"net"                                // tcp or unix listener
+	"net/http"                           // serve api
+	"os"                                 // remove unix socket
+	"strings"                            // manipulate strings
+	"sync"                               // guard event subscribers
+)
+
+const defaultHTTPAddr = "localhost:7380" // serve subcommand address without http-addr
+
+// reply to a POST request
+type apiReply struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// body of a POST request, fields used depend on the action
+type apiRequest struct {
+	Value   string `json:"value"`   // volume: +N%, -N% or N%
+	State   string `json:"state"`   // mute: toggle, on, off
+	Target  string `json:"target"`  // move: sink or source
+	Source  string `json:"source"`  // loopback: source device
+	Sink    string `json:"sink"`    // loopback: sink device
+	Latency int    `json:"latency"` // loopback: milliseconds
+}
+
+// clients waiting for server-sent events
+var (
+	apiClients   = map[chan []byte]bool{}
+	apiClientMux sync.Mutex
+)
+
+// current devices without cards as json
+func apiDevices() []DeviceJSON {
+	m := headlessModel()
+	list := []DeviceJSON{}
+	for _, d := range m.Device {
+		if d.pulsetype != pulsecard {
+			list = append(list, exportDevice(d))
+		}
+	}
+	return list
+}
+
+// check that a request comes from a local client and not from a web page:
+// browsers always send Origin on cross-site requests and a rebound dns name
+// keeps the attacker's host, so only loopback hosts, or the host the api was
+// bound to, are answered
+func guardAPI(bound string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			writeJSON(w, http.StatusForbidden, apiReply{Message: "cross-origin requests are not allowed"})
+			return
+		}
+		if bound != "" && !allowedHost(r.Host, bound) {
+			writeJSON(w, http.StatusForbidden, apiReply{Message: fmt.Sprintf("host %q is not allowed", r.Host)})
+			return
+		}
+		if r.Method == http.MethodPost {
+			kind, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || kind != "application/json" {
+				writeJSON(w, http.StatusUnsupportedMediaType, apiReply{Message: "use Content-Type: application/json"})
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// check whether the Host header names loopback or the address the api listens on
+func allowedHost(host, bound string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" || strings.EqualFold(host, bound) {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// read a json request body, an empty body leaves the defaults
+func decodeRequest(r *http.Request, req *apiRequest) error {
+	err := json.NewDecoder(r.Body).Decode(req)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// write a json value with a status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// translate a subcommand exit code into an http status
+func apiStatus(code int) int {
+	switch code {
+	case exitOK:
+		return http.StatusOK
+	case exitUsage:
+		return http.StatusBadRequest
+	case exitNoDevice:
+		return http.StatusNotFound
+	case exitAmbiguous:
+		return http.StatusConflict
+	}
+	return http.StatusBadGateway
+}
+
+// reply with the outcome of a device function
+func apiResult(w http.ResponseWriter, m *model) {
+	if m.Err != nil {
+		writeJSON(w, http.StatusBadGateway, apiReply{Message: m.Message})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiReply{OK: true, Message: m.Message})
+}
+
+// GET /devices
+func handleDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, apiReply{Message: "use GET"})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiDevices())
+}
+
+// GET /devices/<device> and POST /devices/<device>/<volume|mute|move|default>
+func handleDevice(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/devices/"), "/")
+	m := headlessModel()
+	pos, code := matchDevice(&m, parts[0])
+	if code != exitOK {
+		writeJSON(w, apiStatus(code), apiReply{Message: fmt.Sprintf("no single device matches %q", parts[0])})
+		return
+	}
+	if len(parts) == 1 && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, exportDevice(m.Device[pos]))
+		return
+	}
+	if len(parts) != 2 || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, apiReply{Message: "use GET /devices/<device> or POST /devices/<device>/<action>"})
+		return
+	}
+	var req apiRequest
+	if err := decodeRequest(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiReply{Message: fmt.Sprintf("invalid json: %v", err)})
+		return
+	}
+	m.Cursor.pos = pos
+	d := m.Device[pos]
+	switch parts[1] {
+	case "volume":
+		if !applyVolume(&m, req.Value) {
+			writeJSON(w, http.StatusBadRequest, apiReply{Message: "value must be +N%, -N% or N%"})
+			return
+		}
+	case "mute":
+		if (req.State == "on" && d.pulsemute) || (req.State == "off" && !d.pulsemute) {
+			writeJSON(w, http.StatusOK, apiReply{OK: true, Message: "unchanged"})
+			return
+		}
+		toggleDeviceMute(&m)
+	case "default":
+		switch d.pulsetype {
+		case pulsesink:
+			changeDefaultSink(&m)
+		case pulsesource:
+			changeDefaultSource(&m)
+		default:
+			writeJSON(w, http.StatusBadRequest, apiReply{Message: "only sinks and sources can be default"})
+			return
+		}
+	case "move":
+		target := pulsesink
+		if d.pulsetype == pulseoutput {
+			target = pulsesource
+		} else if d.pulsetype != pulsestream {
+			writeJSON(w, http.StatusBadRequest, apiReply{Message: "only streams and outputs can be moved"})
+			return
+		}
+		dest, code := matchDevice(&m, req.Target, target)
+		if code != exitOK {
+			writeJSON(w, apiStatus(code), apiReply{Message: fmt.Sprintf("no single device matches %q", req.Target)})
+			return
+		}
+		m.Cursor.pos = dest
+		selectDevice(&m)
+		m.Cursor.pos = pos
+		if target == pulsesink {
+			moveStreamToSink(&m)
+		} else {
+			moveOutputToSource(&m)
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, apiReply{Message: fmt.Sprintf("unknown action %q", parts[1])})
+		return
+	}
+	apiResult(w, &m)
+}
+
+// POST /loopback {"source": ..., "sink": ..., "latency": N}
+func handleLoopback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, apiReply{Message: "use POST"})
+		return
+	}
+	req := apiRequest{Latency: varLatency}
+	if err := decodeRequest(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiReply{Message: fmt.Sprintf("invalid json: %v", err)})
+		return
+	}
+	if req.Latency < minLatency || req.Latency > maxLatency {
+		writeJSON(w, http.StatusBadRequest, apiReply{Message: fmt.Sprintf("latency must be %v-%v", minLatency, maxLatency)})
+		return
+	}
+	m := headlessModel()
+	source, code := matchDevice(&m, req.Source, pulsesource)
+	if code != exitOK {
+		writeJSON(w, apiStatus(code), apiReply{Message: fmt.Sprintf("no single source matches %q", req.Source)})
+		return
+	}
+	sink, code := matchDevice(&m, req.Sink, pulsesink)
+	if code != exitOK {
+		writeJSON(w, apiStatus(code), apiReply{Message: fmt.Sprintf("no single sink matches %q", req.Sink)})
+		return
+	}
+	m.Latency = req.Latency // this request only, the interface keeps its own
+	m.Cursor.pos = sink
+	selectDevice(&m)
+	m.Cursor.pos = source
+	loopbackSourceToSink(&m)
+	apiResult(w, &m)
+}
+
+// GET /events streams the device list every time it changes
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, apiReply{Message: "streaming unsupported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	events := make(chan []byte, 8)
+	apiClientMux.Lock()
+	apiClients[events] = true
+	apiClientMux.Unlock()
+	defer func() {
+		apiClientMux.Lock()
+		delete(apiClients, events)
+		apiClientMux.Unlock()
+	}()
+	first, _ := json.Marshal(apiDevices())
+	fmt.Fprintf(w, "event: devices\ndata: %s\n\n", first)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-events:
+			fmt.Fprintf(w, "event: devices\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// send the device list to event clients whenever pulseaudio reports a change
+func broadcastEvents() {
+	var last []byte
+	subscribeEvents(func(event string) {
+		apiClientMux.Lock()
+		waiting := len(apiClients)
+		apiClientMux.Unlock()
+		if waiting == 0 {
+			return
+		}
+		data, _ := json.Marshal(apiDevices())
+		if string(data) == string(last) {
+			return
+		}
+		last = data
+		apiClientMux.Lock()
+		for c := range apiClients {
+			select {
+			case c <- data:
+			default: // slow client, it will catch up on the next change
+			}
+		}
+		apiClientMux.Unlock()
+	})
+}
+
+// listen on "host:port" or "unix:/path", hosts default to localhost
+func apiListen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, "unix:") {
+		path := strings.TrimPrefix(addr, "unix:")
+		os.Remove(path)
+		return net.Listen("unix", path)
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return net.Listen("tcp", addr)
+}
+
+// start the api in the background, returns an error if the address is unusable
+func startAPI(addr string) error {
+	listener, err := apiListen(addr)
+	if err != nil {
+		return err
+	}
+	bound := "" // unix sockets are reached through the file system only
+	if !strings.HasPrefix(addr, "unix:") {
+		bound, _, _ = net.SplitHostPort(addr)
+		if bound == "" { // ":port" listens on localhost
+			bound = "localhost"
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/devices", guardAPI(bound, handleDevices))
+	mux.HandleFunc("/devices/", guardAPI(bound, handleDevice))
+	mux.HandleFunc("/loopback", guardAPI(bound, handleLoopback))
+	mux.HandleFunc("/events", guardAPI(bound, handleEvents))
+	go broadcastEvents()
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// pulsemanager serve [--addr host:port|unix:/path]
+func serveCommand(args []string) int {
+	addr := setHTTPAddr
+	if addr == "" {
+		addr = defaultHTTPAddr
+	}
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVarP(&addr, "addr", "a", addr, "listen address (host:port or unix:/path)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return commandUsage("serve [--addr host:port|unix:/path]")
+	}
+	if err := startAPI(addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	fmt.Printf("serving on %v\n", addr)
+	select {} // serve until killed
+}
@@ -44,6 +44,15 @@ func disableSymbols() {
 	sus_icon = ""
 	idle_icon = ""
 	mic_icon = ""
+	rec_icon = "REC "
+	lock_icon = "LOCK "
+	link_icon = "UNLINK "
+	group_open_icon = "- "
+	group_closed_icon = "+ "
+	member_icon = "  "
+	branch_icon = "` "
+	base_icon = "|"
+	tick_icon = ":"
 	pref_icon = ">>> "
 	suff_icon = " <<<"
 }
@@ -52,27 +61,35 @@ func disableSymbols() {
 func setupModel() model {
 	w := (setWidth / 4) * 3                          // define value that will truncate strings/bar
 	d, dc := buildDevices(buildPulse())              // create PulseDevice from json data
-	c := dc.total - dc.cards                         // exclude cards from count
 	formatProgressBars(d, colorBars(deviceColor), w) // popluate/style progress bars for devices
 	keySetup := initKeymapping()                     // returns address of keymap settings
-	pageSetup := initPager(setItems, c)              // returns paginator settings
 	borderSetup := initBorder(setBorder)
 	return model{
-		Device:      d,               // pulseaudio data and progress model
-		Count:       dc,              // number of each device type
-		Keys:        *keySetup,       // program key bindings
-		Paginator:   pageSetup,       // paging model
-		Help:        initHelp(),      // help model
-		Fullscreen:  setAltscreen,    // program begins in fullscreen
-		ShowMessage: !setNoMessages,  // program begins with messages on
-		ChannelMode: -1,              // control all channels of device (-1=all)
-		VolumeLimit: setMaxVolume,    // set the maximum volume of devices
-		StringLen:   w,               // calculate max string/bar length by setWidth
-		Border:      borderSetup,     // pass border type from config
-		Text:        initText(),      // pass generic lipgloss style for text
-		Selected:    initSelection(), // initalize values to -1/empty string
-		Cursor:      initCursor(),    // pass initial cursor values, if any
-		Display:     initDisplay(),   // pass display attributes
+		Device:       d,               // pulseaudio data and progress model
+		Count:        dc,              // number of each device type
+		Server:       d,               // devices before tree and group layout
+		ServerCount:  dc,              // number of each device type on the server
+		Keys:         *keySetup,       // program key bindings
+		Help:         initHelp(),      // help model
+		Fullscreen:   setAltscreen,    // program begins in fullscreen
+		ShowMessage:  !setNoMessages,  // program begins with messages on
+		ChannelMode:  -1,              // control all channels of device (-1=all)
+		Latency:      varLatency,      // loopback latency in milliseconds
+		VolumeLimit:  setMaxVolume,    // set the maximum volume of devices
+		StringLen:    w,               // calculate max string/bar length by setWidth
+		Border:       borderSetup,     // pass border type from config
+		Text:         initText(),      // pass generic lipgloss style for text
+		Selected:     initSelection(), // initalize values to -1/empty string
+		Cursor:       initCursor(),    // pass initial cursor values, if any
+		Display:      initDisplay(),   // pass display attributes
+		Meters:       setMeters && haveProgram(parec),
+		Peaks:        map[string]float64{},
+		Defaults:     currentDefaults(),
+		Daemon:       daemonPid(),
+		Locks:        append([]VolumeLock{}, setLocks...),
+		VolumeFormat: setVolumeFormat,
+		Unlinked:     map[string]bool{},
+		Collapsed:    map[string]bool{},
 	}
 }
 
@@ -80,6 +97,9 @@ func setupModel() model {
 func (m model) Init() tea.Cmd {
 	var cmds []tea.Cmd             // slice holds multiple commands
 	cmds = append(cmds, tickCmd()) // start timer for update interval
+	if m.Meters {
+		cmds = append(cmds, meterCmd()) // start peak meter frames
+	}
 	m.Message = errorLoad
 	return tea.Batch(cmds...) // use tea.Batch for multiple cmds
 }
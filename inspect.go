@@ -0,0 +1,258 @@
+// /////////////////////////////////////////////////////////////////////////////
+// DEVICE INSPECTOR WITH EVERY SERVER PROPERTY AND OSC 52 COPY
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"bytes"                                  // read pactl output
+	"encoding/base64"                        // encode copied text for osc 52
+	"encoding/json"                          // walk raw pactl json in order
+	"fmt"                                    // format and print text
+	"github.com/charmbracelet/bubbles/key"   // inspector key bindings
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"github.com/charmbracelet/lipgloss"      // style property lines
+	"io"                                     // write the copy sequence at once
+	"os"                                     // write to the terminal
+	"strconv"                                // match device indexes
+	"strings"                                // pad and join keys
+)
+
+const inspectKeyWidth = 28 // widest property name before it is cut
+
+// state of the inspector pane
+type Inspector struct {
+	active bool
+	kind   int           // pulsetype of the inspected device
+	index  int           // pulseindex of the inspected device
+	name   string        // description for the title
+	lines  []InspectLine // flattened server data in server order
+	cursor int           // line copied by y
+	offset int           // first line shown
+}
+
+// one property of the server data, nested keys joined by dots
+type InspectLine struct {
+	key   string
+	value string
+}
+
+// flatten the next json value into lines, keeping the order of the server
+func flattenJSON(dec *json.Decoder, prefix string, out *[]InspectLine) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		empty := true
+		switch t {
+		case '{':
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := flattenJSON(dec, join(fmt.Sprint(k)), out); err != nil {
+					return err
+				}
+				empty = false
+			}
+		case '[':
+			for i := 0; dec.More(); i++ {
+				if err := flattenJSON(dec, fmt.Sprintf("%v[%v]", prefix, i), out); err != nil {
+					return err
+				}
+				empty = false
+			}
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return err
+		}
+		if empty {
+			*out = append(*out, InspectLine{prefix, "-"})
+		}
+	case nil:
+		*out = append(*out, InspectLine{prefix, "null"})
+	default:
+		*out = append(*out, InspectLine{prefix, fmt.Sprint(t)})
+	}
+	return nil
+}
+
+// every property the server reports for a device, false if it is gone
+func inspectDevice(kind, index int) ([]InspectLine, bool) {
+	data, count := getPactlBytes(kind)
+	if count == 0 {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep numbers as the server wrote them
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	for dec.More() {
+		var lines []InspectLine
+		if err := flattenJSON(dec, "", &lines); err != nil {
+			return nil, false
+		}
+		for _, l := range lines {
+			if l.key == "index" && l.value == strconv.Itoa(index) {
+				return lines, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// open the inspector on the device on cursor
+func openInspector(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if isGroup(d) {
+		m.Message = "inspect single streams"
+		return
+	}
+	lines, ok := inspectDevice(d.pulsetype, d.pulseindex)
+	if !ok {
+		m.Message = fmt.Sprintf("no server data: %v", d.pulsedescription)
+		return
+	}
+	m.Inspect = Inspector{active: true, kind: d.pulsetype, index: d.pulseindex, name: d.pulsedescription, lines: lines}
+	m.Message = fmt.Sprintf("inspecting %v: y copies a value", d.pulsedescription)
+}
+
+// read the server data again after a refresh, closing when the device left
+func refreshInspector(m *model) {
+	lines, ok := inspectDevice(m.Inspect.kind, m.Inspect.index)
+	if !ok {
+		m.Inspect.active = false
+		m.Message = fmt.Sprintf("inspected device removed: %v", m.Inspect.name)
+		return
+	}
+	m.Inspect.lines = lines
+	scrollInspector(m, 0)
+}
+
+// number of property lines that fit the terminal
+func inspectRows(m *model) int {
+	rows := listHeight(m) - 5 // name and position of the inspected device
+	if rows < 5 {
+		rows = 5
+	}
+	return rows
+}
+
+// move the inspector cursor, keeping it in view
+func scrollInspector(m *model, step int) {
+	in := &m.Inspect
+	in.cursor += step
+	if in.cursor > len(in.lines)-1 {
+		in.cursor = len(in.lines) - 1
+	}
+	if in.cursor < 0 {
+		in.cursor = 0
+	}
+	rows := inspectRows(m)
+	if in.cursor < in.offset {
+		in.offset = in.cursor
+	}
+	if in.cursor >= in.offset+rows {
+		in.offset = in.cursor - rows + 1
+	}
+}
+
+// keys of the open inspector, true when the key was used up
+func updateInspector(m *model, msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.Keys.Inspect, m.Keys.Escape):
+		m.Inspect.active = false
+		m.Message = "inspector closed"
+	case key.Matches(msg, m.Keys.Up):
+		scrollInspector(m, -1)
+	case key.Matches(msg, m.Keys.Down):
+		scrollInspector(m, 1)
+	case key.Matches(msg, m.Keys.PrevPage):
+		scrollInspector(m, -inspectRows(m))
+	case key.Matches(msg, m.Keys.NextPage):
+		scrollInspector(m, inspectRows(m))
+	case key.Matches(msg, m.Keys.GoToStart):
+		scrollInspector(m, -len(m.Inspect.lines))
+	case key.Matches(msg, m.Keys.GoToEnd):
+		scrollInspector(m, len(m.Inspect.lines))
+	case key.Matches(msg, m.Keys.Copy):
+		if len(m.Inspect.lines) == 0 {
+			return true, nil
+		}
+		l := m.Inspect.lines[m.Inspect.cursor]
+		if err := copyText(l.value); err != nil {
+			m.Message = fmt.Sprintf("error copying: %v", err)
+			return true, nil
+		}
+		m.Message = fmt.Sprintf("copied %v", l.key)
+	default:
+		return !key.Matches(msg, m.Keys.Quit, m.Keys.ShowFullHelp, m.Keys.ShowMessage, m.Keys.Fullscreen), nil
+	}
+	return true, nil
+}
+
+// osc 52 sequence putting text on the terminal clipboard, wrapped so tmux
+// (with allow-passthrough on) or screen hands it to the outer terminal
+func osc52(text string) string {
+	seq := fmt.Sprintf("\x1b]52;c;%v\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case os.Getenv("STY") != "":
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+// put text on the clipboard of the terminal, over ssh as well, with osc 52
+// called from update and written in one piece, so it never lands inside a frame
+func copyText(text string) error {
+	_, err := io.WriteString(os.Stdout, osc52(text))
+	return err
+}
+
+// the properties of the inspected device around the cursor
+func displayInspector(m *model) string {
+	in := m.Inspect
+	style := lipgloss.NewStyle().Foreground(toggleColor[0])
+	chosen := lipgloss.NewStyle().Foreground(toggleColor[1])
+	width := 0
+	for _, l := range in.lines {
+		if len(l.key) > width {
+			width = len(l.key)
+		}
+	}
+	if width > inspectKeyWidth {
+		width = inspectKeyWidth
+	}
+	if width > m.StringLen/2 { // narrow terminals keep half the line for values
+		width = m.StringLen / 2
+	}
+	margin := strings.Repeat(" ", (m.Width-m.StringLen)/2) // center like the device list
+	s := lip.Width(m.Width).Align(center).Foreground(toggleColor[1]).Render(cutText(in.name, m.StringLen)) + "\n\n"
+	end := in.offset + inspectRows(m)
+	if end > len(in.lines) {
+		end = len(in.lines)
+	}
+	for i := in.offset; i < end; i++ {
+		l := in.lines[i]
+		name := fmt.Sprintf("%-*v ", width, cutText(l.key, width-len(" ..")))
+		value := cutText(l.value, m.StringLen-width-3) // empty when there is no room
+		if i == in.cursor {
+			s += margin + chosen.Render("> "+name+value) + "\n"
+			continue
+		}
+		s += margin + style.Render("  "+name) + chosen.Render(value) + "\n"
+	}
+	s += "\n" + lip.Width(m.Width).Align(center).Foreground(toggleColor[0]).Render(fmt.Sprintf("%v-%v of %v", in.offset+1, end, len(in.lines))) + "\n\n"
+	return s
+}
@@ -0,0 +1,67 @@
+// /////////////////////////////////////////////////////////////////////////////
+// VOLUME LIMITS AND STEPS PER DEVICE TYPE AND DEVICE
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"math"   // clamp steps to the limit
+	"regexp" // match device overrides
+)
+
+// limit and step for a device type or device, 0 inherits the global setting
+type VolumeSettings struct {
+	VolumeLimit int `mapstructure:"volumelimit"`
+	VolumeSteps int `mapstructure:"volumesteps"`
+}
+
+// override for devices whose name or description matches a pattern
+type DeviceLimit struct {
+	Match          string `mapstructure:"match"` // regular expression
+	VolumeSettings `mapstructure:",squash"`
+	pattern        *regexp.Regexp
+}
+
+// settings for a device, the first matching override wins over its type
+func volumeSettings(d PulseDevice) VolumeSettings {
+	s := VolumeSettings{}
+	if d.pulsetype >= pulsesink && d.pulsetype <= pulseoutput {
+		s = setTypeLimits[d.pulsetype]
+	}
+	for _, v := range setDeviceLimits {
+		if v.pattern.MatchString(d.pulsename) || v.pattern.MatchString(d.pulsedescription) {
+			if v.VolumeLimit != 0 {
+				s.VolumeLimit = v.VolumeLimit
+			}
+			if v.VolumeSteps != 0 {
+				s.VolumeSteps = v.VolumeSteps
+			}
+			break
+		}
+	}
+	return s
+}
+
+// highest volume the volume keys may reach on a device, --max-volume on the
+// command line wins over the Limits section
+func volumeLimit(m *model, d PulseDevice) float64 {
+	if setMaxVolumeArg {
+		return m.VolumeLimit
+	}
+	if s := volumeSettings(d); s.VolumeLimit != 0 {
+		return float64(s.VolumeLimit)
+	}
+	return m.VolumeLimit
+}
+
+// volume change of one key press on a device
+func volumeStep(d PulseDevice) float64 {
+	if s := volumeSettings(d); s.VolumeSteps != 0 {
+		return float64(s.VolumeSteps)
+	}
+	return setVolume
+}
+
+// step that does not take a channel past the limit, 0 if it is already there
+func clampStep(step, volume, limit float64) float64 {
+	return math.Max(0, math.Min(step, limit-volume))
+}
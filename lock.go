@@ -0,0 +1,183 @@
+// /////////////////////////////////////////////////////////////////////////////
+// VOLUME LOCKS ENFORCED ON EVERY REFRESH
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"     // format and print text
+	"os/exec" // run external system commands
+	"strconv" // convert types to/from string
+	"strings" // manipulate strings
+)
+
+// lock modes, config values
+const (
+	lockFixed = "fixed" // keep every channel at the lock volume
+	lockMax   = "max"   // keep every channel at or below the lock volume
+)
+
+// a volume lock from the config file or the lock key
+type VolumeLock struct {
+	Device string `mapstructure:"device"` // [type:]name or description substring
+	Mode   string `mapstructure:"mode"`   // fixed or max
+	Volume int    `mapstructure:"volume"` // percent
+	exact  bool   // set by the lock key, pins one device instead of a substring
+	kind   int    // pulsetype of an exact lock
+	index  int    // pulseindex of an exact lock on a stream or output
+}
+
+// lock pinned to the device on cursor: sinks and sources by name, which lasts
+// across restarts of the server, streams and outputs by index since several
+// of them often share a name
+func exactLock(d PulseDevice) VolumeLock {
+	return VolumeLock{
+		Device: getDeviceType(d.pulsetype) + ":" + d.pulsename,
+		Mode:   lockFixed,
+		Volume: deviceVolume(d),
+		exact:  true,
+		kind:   d.pulsetype,
+		index:  d.pulseindex,
+	}
+}
+
+// check whether a lock applies to a device
+func lockMatches(l VolumeLock, d PulseDevice) bool {
+	if l.exact {
+		if d.pulsetype != l.kind {
+			return false
+		}
+		if d.pulsetype == pulsestream || d.pulsetype == pulseoutput {
+			return d.pulseindex == l.index
+		}
+		return l.Device == getDeviceType(d.pulsetype)+":"+d.pulsename
+	}
+	query := l.Device
+	for t := pulsesink; t <= pulseoutput; t++ {
+		prefix := getDeviceType(t) + ":"
+		if strings.HasPrefix(query, prefix) {
+			if d.pulsetype != t {
+				return false
+			}
+			query = strings.TrimPrefix(query, prefix)
+		}
+	}
+	if d.pulsetype == pulsecard || query == "" {
+		return false
+	}
+	lower := strings.ToLower(query)
+	return d.pulsename == query ||
+		strings.Contains(strings.ToLower(d.pulsedescription), lower) ||
+		strings.Contains(strings.ToLower(d.pulsename), lower)
+}
+
+// first lock that applies to a device, -1 if none
+func findLock(locks []VolumeLock, d PulseDevice) int {
+	for i, l := range locks {
+		if lockMatches(l, d) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pactl volume command for a device type
+func volumeCmd(t int) string {
+	switch t {
+	case pulsestream:
+		return stream_vol_cmd
+	case pulsesource:
+		return source_vol_cmd
+	case pulseoutput:
+		return output_vol_cmd
+	}
+	return sink_vol_cmd
+}
+
+// channel volumes a lock allows, false if the device already complies
+func lockVolumes(l VolumeLock, d PulseDevice) ([]float64, bool) {
+	target := float64(l.Volume)
+	changed := false
+	vol := make([]float64, len(d.pulsevolume))
+	for i, v := range d.pulsevolume {
+		vol[i] = v
+		if (l.Mode == lockFixed && v != target) || (l.Mode == lockMax && v > target) {
+			vol[i] = target
+			changed = true
+		}
+	}
+	return vol, changed
+}
+
+// locks set with the lock key, the ones left to enforce while a daemon
+// already enforces those from the config file
+func keyLocks(locks []VolumeLock) []VolumeLock {
+	var exact []VolumeLock
+	for _, l := range locks {
+		if l.exact {
+			exact = append(exact, l)
+		}
+	}
+	return exact
+}
+
+// re-apply locks to devices that violate them, updating their volume in place
+// returns one message per corrected device
+func enforceLocks(locks []VolumeLock, d []PulseDevice) []string {
+	var fixed []string
+	for i := range d {
+		n := findLock(locks, d[i])
+		if n < 0 {
+			continue
+		}
+		vol, changed := lockVolumes(locks[n], d[i])
+		if !changed {
+			continue
+		}
+		args := []string{volumeCmd(d[i].pulsetype), strconv.Itoa(d[i].pulseindex)}
+		for _, v := range vol {
+			args = append(args, fmt.Sprintf("%v%%", v))
+		}
+		if err := exec.Command(pactl, args...).Run(); err != nil {
+			fixed = append(fixed, fmt.Sprintf("error enforcing lock: %v", d[i].pulsedescription))
+			continue
+		}
+		fixed = append(fixed, fmt.Sprintf("lock: %v %v%% -> %v%%",
+			d[i].pulsedescription, deviceVolume(d[i]), locks[n].Volume))
+		d[i].pulsevolume = vol
+	}
+	return fixed
+}
+
+// cycle the lock of the device on cursor: none, fixed, max, none
+func toggleLock(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if isGroup(d) {
+		m.Message = "lock single streams"
+		return
+	}
+	n := findLock(m.Locks, d)
+	switch {
+	case n < 0:
+		l := exactLock(d)
+		m.Locks = append(m.Locks, l)
+		m.Message = fmt.Sprintf("locked at %v%%: %v", l.Volume, d.pulsedescription)
+	case m.Locks[n].Mode == lockFixed:
+		m.Locks[n].Mode = lockMax
+		m.Message = fmt.Sprintf("locked below %v%%: %v", m.Locks[n].Volume, d.pulsedescription)
+	default:
+		m.Locks = append(m.Locks[:n], m.Locks[n+1:]...)
+		m.Message = fmt.Sprintf("unlocked: %v", d.pulsedescription)
+	}
+}
+
+// lock icon shown in front of locked devices
+func displayLock(m *model, d PulseDevice) string {
+	n := findLock(m.Locks, d)
+	if n < 0 {
+		return ""
+	}
+	if m.Locks[n].Mode == lockMax {
+		return fmt.Sprintf("%v≤%v%% ", lock_icon, m.Locks[n].Volume)
+	}
+	return fmt.Sprintf("%v%v%% ", lock_icon, m.Locks[n].Volume)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"                                    // format and print text
 	tea "github.com/charmbracelet/bubbletea" // main cli application library
 	flag "github.com/cornfeedhobo/pflag"     // command line flag parsing
+	"os"                                     // read arguments and exit with status
 )
 
 func main() {
@@ -17,7 +18,17 @@ func main() {
 	validateConfig(&config)     // validate config file and load its settings
 	loadConfigColors(&config, &deviceColor, &toggleColor)
 	setBorder = loadConfigStyles(&config)
+	loadConfigHooks(&config)
+	loadConfigLocks(&config)
+	loadConfigLimits(&config)
 	initFlags()
+	if isCommand(os.Args) { // run headless subcommand using config values and flags
+		if !haveProgram("pactl") {
+			fmt.Fprintln(os.Stderr, errorMsg1)
+			os.Exit(exitFailed)
+		}
+		os.Exit(runCommand(os.Args))
+	}
 	flag.Parse()
 	validateFlags()
 	// check for dependencies
@@ -36,7 +47,25 @@ func main() {
 	} else {
 		p = tea.NewProgram(setupModel())
 	}
+	stopControl := serveControl(p) // accept requests from pulsemanager ctl
+	if setHTTPAddr != "" {
+		if err := startAPI(setHTTPAddr); err != nil {
+			fmt.Println(errorOut(fmt.Sprintf("http api: %v", err)))
+			return
+		}
+	}
+	if setMetricsAddr != "" {
+		if err := startMetrics(setMetricsAddr); err != nil {
+			fmt.Println(errorOut(fmt.Sprintf("metrics: %v", err)))
+			return
+		}
+	}
 	if err := p.Start(); err != nil {
 		fmt.Printf("Error initializing program: %v", err)
 	}
+	if stopControl != nil {
+		stopControl()
+	}
+	stopMeters()     // do not leave parec processes behind
+	stopRecordings() // finalize wav headers of unfinished recordings
 }
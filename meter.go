@@ -0,0 +1,181 @@
+// /////////////////////////////////////////////////////////////////////////////
+// LIVE PEAK METERS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/binary"                        // decode raw samples from parec
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"io"                                     // read sample stream
+	"math"                                   // store peaks as float bits
+	"os/exec"                                // run external system commands
+	"strconv"                                // convert types to/from string
+	"sync"                                   // guard running meters
+	"sync/atomic"                            // share latest peak between goroutines
+)
+
+const (
+	parec       = "parec"              // record client shipped with pactl
+	meterClient = "pulsemanager-meter" // client name used to hide meter streams
+	meterRate   = 8000                 // sample rate requested for metering
+	meterDecay  = 0.85                 // how quickly a meter falls between frames
+)
+
+// a parec process reading one device and the loudest sample of its last frame
+type meter struct {
+	cmd  *exec.Cmd
+	peak atomic.Uint64 // math.Float64bits of 0.0-1.0 peak
+}
+
+var (
+	meters   = map[string]*meter{} // running meters keyed by meterKey()
+	meterMux sync.Mutex
+)
+
+// identify a device across refreshes
+func meterKey(d PulseDevice) string {
+	return fmt.Sprintf("%v:%v", d.pulsetype, d.pulseindex)
+}
+
+// build parec arguments for a device, returns false when it cannot be metered
+func meterArgs(m *model, d PulseDevice) ([]string, bool) {
+	var device string
+	var args []string
+	switch d.pulsetype {
+	case pulsesink:
+		device = d.pulsemonitor
+	case pulsesource:
+		device = d.pulsename
+	case pulsestream: // monitor the sink the stream plays on, restricted to the stream
+		for _, v := range m.Server {
+			if v.pulsetype == pulsesink && v.pulseindex == d.pulsesinkindex {
+				device = v.pulsemonitor
+			}
+		}
+		args = append(args, "--monitor-stream="+strconv.Itoa(d.pulseindex))
+	case pulseoutput: // outputs are metered by the source they record from
+		for _, v := range m.Server {
+			if v.pulsetype == pulsesource && v.pulseindex == d.pulsesourceindex {
+				device = v.pulsename
+			}
+		}
+	}
+	if device == "" {
+		return nil, false
+	}
+	latency := 1000 / setMeterRate
+	args = append(args,
+		"--device="+device,
+		"--client-name="+meterClient,
+		"--raw",
+		"--format=s16le",
+		"--channels=1",
+		"--rate="+strconv.Itoa(meterRate),
+		"--latency-msec="+strconv.Itoa(latency),
+	)
+	return args, true
+}
+
+// launch parec for a device and keep its peak updated until the process exits
+func startMeter(key string, args []string) {
+	cmd := exec.Command(parec, args...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	mt := &meter{cmd: cmd}
+	meters[key] = mt
+	go func() {
+		frame := make([]byte, 2*(meterRate/setMeterRate))
+		for {
+			n, err := io.ReadFull(out, frame)
+			var peak float64
+			for i := 0; i+1 < n; i += 2 {
+				s := math.Abs(float64(int16(binary.LittleEndian.Uint16(frame[i:]))) / 32768)
+				if s > peak {
+					peak = s
+				}
+			}
+			mt.peak.Store(math.Float64bits(peak))
+			if err != nil {
+				cmd.Wait()
+				return
+			}
+		}
+	}()
+}
+
+// terminate a meter process
+func stopMeter(key string) {
+	if mt, ok := meters[key]; ok {
+		mt.cmd.Process.Kill()
+		delete(meters, key)
+	}
+}
+
+// terminate every meter process (called on exit or when meters are toggled off)
+func stopMeters() {
+	meterMux.Lock()
+	defer meterMux.Unlock()
+	for k := range meters {
+		stopMeter(k)
+	}
+}
+
+// run meters for visible, unsuspended devices and collect their latest peaks
+func syncMeters(m *model) {
+	meterMux.Lock()
+	defer meterMux.Unlock()
+	want := map[string]bool{}
+	if m.Meters && m.Count.total-m.Count.cards > 0 {
+		start, end := visibleRange(m)
+		for _, d := range m.Device[start:end] {
+			if d.pulsestate == suspended_state || isGroup(d) { // headers have no stream to monitor
+				continue
+			}
+			key := meterKey(d)
+			want[key] = true
+			if _, ok := meters[key]; ok {
+				continue
+			}
+			if args, ok := meterArgs(m, d); ok {
+				startMeter(key, args)
+			}
+		}
+	}
+	for k := range meters {
+		if !want[k] {
+			stopMeter(k)
+		}
+	}
+	for k := range m.Peaks {
+		if _, ok := meters[k]; !ok {
+			delete(m.Peaks, k)
+		}
+	}
+	for k, mt := range meters {
+		peak := math.Float64frombits(mt.peak.Load())
+		m.Peaks[k] = math.Max(peak, m.Peaks[k]*meterDecay)
+	}
+}
+
+// turn peak meters on/off
+func toggleMeters(m *model) tea.Cmd {
+	m.Meters = !m.Meters
+	if !m.Meters {
+		stopMeters()
+		m.Message = "peak meters off"
+		return nil
+	}
+	if !haveProgram(parec) {
+		m.Meters = false
+		m.Message = "peak meters require parec"
+		return nil
+	}
+	m.Message = "peak meters on"
+	return meterCmd()
+}
@@ -0,0 +1,154 @@
+// /////////////////////////////////////////////////////////////////////////////
+// PROMETHEUS METRICS EXPORTER
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                // format and print text
+	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
+	"net/http"                           // serve metrics
+	"os"                                 // write errors to stderr
+	"strconv"                            // convert types to/from string
+	"strings"                            // manipulate strings
+)
+
+const defaultMetricsAddr = "localhost:9389" // metrics subcommand address without metrics-addr
+
+// device states reported by pactl, exported as one series each
+var metricStates = []string{"RUNNING", "IDLE", "SUSPENDED"}
+
+// one gauge and its samples in text exposition format
+type metricFamily struct {
+	name    string
+	help    string
+	samples []string
+}
+
+// add a sample with labels given as name, value pairs
+func (f *metricFamily) add(value float64, labels ...string) {
+	var l []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		l = append(l, fmt.Sprintf("%v=%q", labels[i], escapeLabel(labels[i+1])))
+	}
+	f.samples = append(f.samples, fmt.Sprintf("%v{%v} %v", f.name, strings.Join(l, ","),
+		strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+// strip characters %q would escape differently than prometheus
+func escapeLabel(s string) string {
+	return strings.NewReplacer("\n", " ", "\t", " ").Replace(s)
+}
+
+// bluetooth battery level as a number, false if the device reports none
+func batteryPercent(battery string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(battery, "%")), 64)
+	return v, err == nil
+}
+
+// convert a bool into a gauge value
+func gauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// render all gauges for the current devices
+func collectMetrics(d []PulseDevice) string {
+	volume := metricFamily{name: "pulsemanager_volume_percent", help: "Volume of each channel in percent."}
+	muted := metricFamily{name: "pulsemanager_muted", help: "1 if the device is muted."}
+	state := metricFamily{name: "pulsemanager_device_state", help: "1 for the current state of a sink or source."}
+	streams := metricFamily{name: "pulsemanager_sink_streams", help: "Number of streams playing to a sink."}
+	latency := metricFamily{name: "pulsemanager_loopback_latency_seconds", help: "Latency of a loopback from a source."}
+	battery := metricFamily{name: "pulsemanager_battery_percent", help: "Bluetooth battery level in percent."}
+	for _, v := range d {
+		if v.pulsetype == pulsecard {
+			if level, ok := batteryPercent(v.pulsebattery); ok {
+				battery.add(level, "type", "card", "name", v.pulsedescription)
+			}
+			continue
+		}
+		kind := getDeviceType(v.pulsetype)
+		index := strconv.Itoa(v.pulseindex)
+		for c, vol := range v.pulsevolume {
+			channel := strconv.Itoa(c)
+			if c < len(v.pulsechannels) {
+				channel = v.pulsechannels[c]
+			}
+			volume.add(vol, "type", kind, "index", index, "name", v.pulsename, "channel", channel)
+		}
+		muted.add(gauge(v.pulsemute), "type", kind, "index", index, "name", v.pulsename)
+		if v.pulsetype == pulsesink || v.pulsetype == pulsesource {
+			for _, s := range metricStates {
+				state.add(gauge(v.pulsestate == s), "type", kind, "name", v.pulsename, "state", strings.ToLower(s))
+			}
+			if level, ok := batteryPercent(v.pulsebattery); ok {
+				battery.add(level, "type", kind, "name", v.pulsename)
+			}
+		}
+		if v.pulsetype == pulsesink {
+			count := 0
+			for _, s := range d {
+				if s.pulsetype == pulsestream && s.pulsesinkindex == v.pulseindex {
+					count++
+				}
+			}
+			streams.add(float64(count), "type", kind, "name", v.pulsename)
+		}
+		if v.pulsetype == pulseoutput && v.pulsedriver == loopback_c {
+			source := ""
+			for _, s := range d {
+				if s.pulsetype == pulsesource && s.pulseindex == v.pulsesourceindex {
+					source = s.pulsename
+				}
+			}
+			latency.add(v.pulselatency/1e6, "type", kind, "name", v.pulsename, "module", v.pulsemodule, "source", source)
+		}
+	}
+	var b strings.Builder
+	for _, f := range []metricFamily{volume, muted, state, streams, latency, battery} {
+		fmt.Fprintf(&b, "# HELP %v %v\n# TYPE %v gauge\n", f.name, f.help, f.name)
+		for _, s := range f.samples {
+			b.WriteString(s + "\n")
+		}
+	}
+	return b.String()
+}
+
+// GET /metrics
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	d, _ := buildDevices(buildPulse())
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprint(w, collectMetrics(d))
+}
+
+// start the exporter in the background, returns an error if the address is unusable
+func startMetrics(addr string) error {
+	listener, err := apiListen(addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// pulsemanager metrics [--addr host:port|unix:/path]
+func metricsCommand(args []string) int {
+	addr := setMetricsAddr
+	if addr == "" {
+		addr = defaultMetricsAddr
+	}
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	fs.StringVarP(&addr, "addr", "a", addr, "listen address (host:port or unix:/path)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return commandUsage("metrics [--addr host:port|unix:/path]")
+	}
+	if err := startMetrics(addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailed
+	}
+	fmt.Printf("serving metrics on %v/metrics\n", addr)
+	select {} // serve until killed
+}
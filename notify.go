@@ -0,0 +1,154 @@
+// /////////////////////////////////////////////////////////////////////////////
+// DESKTOP NOTIFICATIONS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"os"                                     // open notification log
+	"os/exec"                                // run external system commands
+	"path/filepath"                          // build log path
+	"time"                                   // rate limit notifications
+)
+
+const (
+	notifySend     = "notify-send"    // freedesktop notification client
+	notifyOff      = "off"            // config value disabling notifications
+	notifyOn       = "send"           // config value sending notifications
+	notifyLog      = "log"            // config value logging instead of sending
+	notifyInterval = 30 * time.Second // same notification is not repeated sooner
+	notifyLogFile  = "notifications.log"
+)
+
+type NotifyMsg struct{ err error } // result of notify-send
+
+// a notification built from an audio event
+type notification struct {
+	key     string // rate limit key, event and device
+	summary string
+	body    string
+	icon    string
+	urgency string
+}
+
+var lastNotified = map[string]time.Time{} // rate limit, only touched from Update
+
+// check a configured notification mode
+func isNotifyMode(mode string) bool {
+	return mode == notifyOff || mode == notifyOn || mode == notifyLog
+}
+
+// build the notification for an event, false if the event is not notified
+func buildNotification(e AudioEvent) (notification, bool) {
+	d := e.device
+	n := notification{
+		key:     e.name + ":" + d.pulsename,
+		body:    d.pulsedescription,
+		icon:    "audio-card",
+		urgency: "normal",
+	}
+	if d.pulsebus == "bluetooth" {
+		n.icon = "audio-headphones"
+	}
+	switch e.name {
+	case event_added:
+		if d.pulsetype != pulsesink { // a headset adds a sink and a source, tell once
+			return n, false
+		}
+		n.summary = "Audio device connected"
+		if d.pulsebattery != "" {
+			n.body = fmt.Sprintf("%v (battery %v)", d.pulsedescription, d.pulsebattery)
+		}
+	case event_battery:
+		if d.pulsedevstring != "" {
+			n.key = e.name + ":" + d.pulsedevstring // sink and source share a battery
+		}
+		n.summary = "Battery low"
+		n.body = fmt.Sprintf("%v: %v", d.pulsedescription, d.pulsebattery)
+		n.icon = "battery-caution"
+		n.urgency = "critical"
+	case event_default:
+		n.summary = "Default output changed"
+	case event_input:
+		n.summary = "Default input changed"
+		n.icon = "audio-input-microphone"
+	default:
+		return n, false
+	}
+	return n, true
+}
+
+// drop notifications sent recently, called from Update
+func rateLimit(n notification, now time.Time) bool {
+	if last, ok := lastNotified[n.key]; ok && now.Sub(last) < notifyInterval {
+		return false
+	}
+	lastNotified[n.key] = now
+	return true
+}
+
+// location of the log written in test mode
+func notifyLogPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "pulsemanager", notifyLogFile)
+}
+
+// send or log one notification
+func sendNotification(n notification) error {
+	if setNotify == notifyLog {
+		path := notifyLogPath()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = fmt.Fprintf(f, "%v [%v] %v: %v\n", time.Now().Format(time.RFC3339), n.urgency, n.summary, n.body)
+		return err
+	}
+	return exec.Command(notifySend,
+		"--app-name="+programName,
+		"--icon="+n.icon,
+		"--urgency="+n.urgency,
+		n.summary, n.body).Run()
+}
+
+// notifications for events that pass the rate limit
+func pendingNotifications(events []AudioEvent) []notification {
+	var list []notification
+	if setNotify == notifyOff {
+		return list
+	}
+	now := time.Now()
+	for _, e := range events {
+		if n, ok := buildNotification(e); ok && rateLimit(n, now) {
+			list = append(list, n)
+		}
+	}
+	return list
+}
+
+// send notifications for events outside of Update
+func notifyCmds(events []AudioEvent) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, n := range pendingNotifications(events) {
+		n := n
+		cmds = append(cmds, func() tea.Msg {
+			return NotifyMsg{sendNotification(n)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// show a failed notification in the message line
+func reportNotify(m *model, msg NotifyMsg) {
+	if msg.err != nil {
+		m.Message = fmt.Sprintf("notification failed: %v", msg.err)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"bufio"         // read/write input/output
 	"encoding/json" // decode json data streams
 	"fmt"           // format and print text
+	"math"          // decibel value of silence
 	"os/exec"       // run external system commands
+	"sort"          // order card profiles
 	"strconv"       // convert types to/from string
 	"strings"       // manipulate strings
 )
@@ -28,7 +30,17 @@ type Pulse struct {
 	Volume      map[string]interface{} `json:"volume"`
 	Port        string                 `json:"active_port"`
 	Latency     float64                `json:"source_latency_usec"`
-	Properties  struct {
+	Monitor     string                 `json:"monitor_source"`
+	Profile     string                 `json:"active_profile"`
+	Profiles    map[string]struct {
+		Description string `json:"description"`
+		Priority    int    `json:"priority"`
+		Available   bool   `json:"available"`
+	} `json:"profiles"`
+	BaseVolume struct {
+		Percent string `json:"value_percent"`
+	} `json:"base_volume"`
+	Properties struct {
 		Icon         string `json:"application.icon_name"`
 		Title        string `json:"media.name"`
 		Name         string `json:"application.name"`
@@ -70,6 +82,26 @@ func (p Pulse) getTitle() string          { return p.Properties.Title }
 func (p Pulse) getFormattedTitle() string { return p.FormattedTitle }
 func (p Pulse) getChannelCount() int      { return len(p.ChannelList) }
 func (p Pulse) getCardName() string       { return p.Properties.Card }
+func (p Pulse) getMonitor() string        { return p.Monitor }
+func (p Pulse) getProfile() string        { return p.Profile }
+
+// available profiles of a card, highest priority first like pavucontrol
+func (p Pulse) getProfiles() []CardProfile {
+	var list []CardProfile
+	for name, v := range p.Profiles {
+		if v.Available {
+			list = append(list, CardProfile{name, v.Description})
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		pa, pb := p.Profiles[list[a].name].Priority, p.Profiles[list[b].name].Priority
+		if pa != pb {
+			return pa > pb
+		}
+		return list[a].name < list[b].name
+	})
+	return list
+}
 func (p Pulse) getChannelList() []string {
 	var channels []string
 	sep := ","
@@ -104,6 +136,46 @@ func (p Pulse) getChannelVolume() []float64 {
 	}
 	return fvolume
 }
+
+// raw volume value of each channel (65536 is 100%)
+func (p Pulse) getChannelValue() []int {
+	var values []int
+	for _, v := range p.ChannelList {
+		entry, ok := p.Volume[v].(map[string]interface{})
+		if !ok {
+			values = append(values, 0)
+			continue
+		}
+		value, _ := entry["value"].(float64) // json numbers decode as float64
+		values = append(values, int(value))
+	}
+	return values
+}
+
+// decibel volume of each channel, -Inf for silence
+func (p Pulse) getChannelDB() []float64 {
+	var db []float64
+	for _, v := range p.ChannelList {
+		entry, ok := p.Volume[v].(map[string]interface{})
+		if !ok {
+			db = append(db, math.Inf(-1))
+			continue
+		}
+		text, _ := entry["db"].(string)
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(text, "dB")), 64)
+		if err != nil { // "-inf dB"
+			value = math.Inf(-1)
+		}
+		db = append(db, value)
+	}
+	return db
+}
+
+// hardware 0 dB point of a sink or source in percent, 0 if not reported
+func (p Pulse) getBaseVolume() float64 {
+	value, _ := strconv.ParseFloat(strings.Trim(p.BaseVolume.Percent, "%"), 64)
+	return value
+}
 func (p Pulse) getModule() string {
 	s := strings.Trim(fmt.Sprintf("%v", p.Module), "\"")
 	return s
@@ -137,6 +209,32 @@ func getPactlBytes(pulsetype int) ([]byte, int) {
 	count := strings.Count(string(cmd), "\"index\":")
 	return cmd, count
 }
+
+// get the name of the default sink or source ("get-default-sink"/"get-default-source")
+func getDefaultDevice(cmd string) string {
+	out, err := exec.Command(pactl, cmd).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// run "pactl subscribe" and pass each event line to fn until the subscription ends
+func subscribeEvents(fn func(event string)) error {
+	cmd := exec.Command(pactl, subscribe_cmd)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() { // "Event 'change' on sink #0"
+		fn(scanner.Text())
+	}
+	return cmd.Wait()
+}
 func getStreamText() string {
 	cmd, err := exec.Command("pactl", "-f", "text", "list", "sink-inputs").Output()
 	if err != nil {
@@ -186,6 +284,9 @@ func buildPulse() ([]Pulse, DeviceCount) {
 			continue
 		}
 		json.Unmarshal([]byte(pactljson), &pulsearray)
+		if v == pulseoutput {
+			pulsearray = excludeMeters(pulsearray)
+		}
 		if v == pulsestream {
 			pactltext := getStreamText()
 			titles = formatStreamText(pactltext)
@@ -218,6 +319,18 @@ func buildPulse() ([]Pulse, DeviceCount) {
 	return devices, dc
 }
 
+// remove the record streams opened by our own peak meters
+func excludeMeters(p []Pulse) []Pulse {
+	var kept []Pulse
+	for _, v := range p {
+		if v.getAppName() == meterClient {
+			continue
+		}
+		kept = append(kept, v)
+	}
+	return kept
+}
+
 // generate complete PulseDevice slice from relevant categories for device types
 func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 	var devices []PulseDevice
@@ -238,13 +351,17 @@ func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 		devices[i].pulsecount = p[i].getChannelCount()
 		devices[i].pulsechannels = p[i].getChannelList()
 		devices[i].pulsevolume = p[i].getChannelVolume()
+		devices[i].pulsevalue = p[i].getChannelValue()
+		devices[i].pulsedb = p[i].getChannelDB()
 		devices[i].pulsecard = p[i].getCardName()
 		devices[i].pulsemute = p[i].getMute()
 		devices[i].pulsebalance = p[i].getBalance()
 		devices[i].pulseport = p[i].getPort()
+		devices[i].pulsebase = p[i].getBaseVolume()
 		devices[i].pulsebus = p[i].getBus()
 		devices[i].pulsebattery = p[i].getBattery()
 		devices[i].pulsedevstring = p[i].getDevString()
+		devices[i].pulsemonitor = p[i].getMonitor()
 		index++
 	}
 	for i := index; i < d.sinks+d.streams; i++ {
@@ -254,10 +371,13 @@ func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 		devices[i].pulsedriver = p[i].getDriver()
 		devices[i].pulsemodule = p[i].getModule()
 		devices[i].pulsename = p[i].getBinaryName()
+		devices[i].pulseapp = p[i].getAppName()
 		devices[i].pulsedescription = p[i].getFormattedTitle()
 		devices[i].pulsecount = p[i].getChannelCount()
 		devices[i].pulsechannels = p[i].getChannelList()
 		devices[i].pulsevolume = p[i].getChannelVolume()
+		devices[i].pulsevalue = p[i].getChannelValue()
+		devices[i].pulsedb = p[i].getChannelDB()
 		devices[i].pulsemute = p[i].getMute()
 		devices[i].pulsebalance = p[i].getBalance()
 		devices[i].pulsepid = p[i].getPID()
@@ -276,9 +396,12 @@ func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 		devices[i].pulsecount = p[i].getChannelCount()
 		devices[i].pulsechannels = p[i].getChannelList()
 		devices[i].pulsevolume = p[i].getChannelVolume()
+		devices[i].pulsevalue = p[i].getChannelValue()
+		devices[i].pulsedb = p[i].getChannelDB()
 		devices[i].pulsemute = p[i].getMute()
 		devices[i].pulsebalance = p[i].getBalance()
 		devices[i].pulseport = p[i].getPort()
+		devices[i].pulsebase = p[i].getBaseVolume()
 		devices[i].pulsebus = p[i].getBus()
 		devices[i].pulsebattery = p[i].getBattery()
 		devices[i].pulsedevstring = p[i].getDevString()
@@ -296,6 +419,8 @@ func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 		devices[i].pulsecount = p[i].getChannelCount()
 		devices[i].pulsechannels = p[i].getChannelList()
 		devices[i].pulsevolume = p[i].getChannelVolume()
+		devices[i].pulsevalue = p[i].getChannelValue()
+		devices[i].pulsedb = p[i].getChannelDB()
 		devices[i].pulsemute = p[i].getMute()
 		devices[i].pulsebalance = p[i].getBalance()
 		devices[i].pulselatency = p[i].getLatency()
@@ -305,6 +430,15 @@ func buildDevices(p []Pulse, d DeviceCount) ([]PulseDevice, DeviceCount) {
 	for i := index; i < d.sinks+d.streams+d.sources+d.outputs+d.cards; i++ {
 		devices[i].pulsetype = pulsecard
 		devices[i].pulseindex = p[i].getIndex()
+		devices[i].pulsename = p[i].getName()
+		devices[i].pulsedriver = p[i].getDriver()
+		devices[i].pulseprofile = p[i].getProfile()
+		devices[i].pulseprofiles = p[i].getProfiles()
+		for _, v := range devices[i].pulseprofiles {
+			if v.name == devices[i].pulseprofile {
+				devices[i].pulseprofiletext = v.description
+			}
+		}
 		devices[i].pulsemodule = p[i].getModule()
 		devices[i].pulsedescription = p[i].getPDescription()
 		devices[i].pulsebattery = p[i].getBattery()
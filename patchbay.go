@@ -0,0 +1,408 @@
+// /////////////////////////////////////////////////////////////////////////////
+// PATCHBAY GRAPH OF SOURCES, LOOPBACKS, STREAMS, OUTPUTS AND SINKS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                    // format and print text
+	"github.com/charmbracelet/bubbles/key"   // navigate the graph
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"github.com/charmbracelet/lipgloss"      // color the graph
+	"os/exec"                                // run external system commands
+	"sort"                                   // order nodes by their routes
+	"strings"                                // build graph lines
+)
+
+const (
+	patchGap    = 8 // columns between boxes, room for the routes
+	patchHeight = 4 // lines taken by a box and the space under it
+)
+
+// columns of the graph, signal flows left to right
+const (
+	patchSources = iota // sources
+	patchMiddle         // outputs, loopbacks and streams
+	patchSinks          // sinks
+)
+
+var (
+	patchLeft  = key.NewBinding(key.WithKeys("h", "left"))
+	patchRight = key.NewBinding(key.WithKeys("l", "right"))
+)
+
+// box in the graph standing for a device in the list
+type patchNode struct {
+	pos   int    // position of the device in m.Device
+	col   int    // column of the box
+	row   int    // row of the box in its column
+	label string // text inside the box
+}
+
+// route between two boxes
+type patchEdge struct {
+	from, to int // nodes the route leaves and enters
+}
+
+// output recording for the same loopback module as a loopback stream
+func loopbackPartner(m *model, d PulseDevice) (PulseDevice, bool) {
+	for _, v := range m.Server {
+		if v.pulsetype == pulseoutput && v.pulsedriver == loopback_c && v.pulsemodule == d.pulsemodule {
+			return v, true
+		}
+	}
+	return PulseDevice{}, false
+}
+
+// text shown inside the box of a device
+func patchLabel(d PulseDevice) string {
+	switch {
+	case d.pulsedriver == loopback_c:
+		return fmt.Sprintf("loopback #%v", d.pulsemodule)
+	case d.pulsetype == pulseoutput:
+		return d.pulsename
+	}
+	return d.pulsedescription
+}
+
+// boxes and routes of the listed devices, loopback streams and outputs
+// share one box in the middle column
+func buildPatchbay(m *model) ([]patchNode, []patchEdge) {
+	var nodes []patchNode
+	devices := m.Device[:m.Count.total-m.Count.cards]
+	find := func(t, index int) int { // node of a sink or source
+		for i, n := range nodes {
+			d := devices[n.pos]
+			if d.pulsetype == t && d.pulseindex == index && n.col != patchMiddle {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, d := range devices {
+		switch d.pulsetype {
+		case pulsesource:
+			nodes = append(nodes, patchNode{pos: i, col: patchSources, label: patchLabel(d)})
+		case pulsesink:
+			nodes = append(nodes, patchNode{pos: i, col: patchSinks, label: patchLabel(d)})
+		}
+	}
+	rows := map[int]int{} // first row a column has free
+	for i := range nodes {
+		nodes[i].row = rows[nodes[i].col]
+		rows[nodes[i].col]++
+	}
+	// middle boxes sorted by the device they route to or from
+	type middle struct {
+		pos, kind, near int
+	}
+	var mid []middle
+	for i, d := range devices {
+		switch {
+		case d.pulsetype == pulseoutput && d.pulsedriver == loopback_c:
+			continue // drawn with its loopback stream
+		case d.pulsetype == pulseoutput:
+			mid = append(mid, middle{i, 0, nodeRow(nodes, find(pulsesource, d.pulsesourceindex))})
+		case d.pulsetype == pulsestream && d.pulsedriver == loopback_c:
+			mid = append(mid, middle{i, 1, nodeRow(nodes, find(pulsesink, d.pulsesinkindex))})
+		case d.pulsetype == pulsestream:
+			mid = append(mid, middle{i, 2, nodeRow(nodes, find(pulsesink, d.pulsesinkindex))})
+		}
+	}
+	sort.SliceStable(mid, func(a, b int) bool {
+		if mid[a].kind != mid[b].kind {
+			return mid[a].kind < mid[b].kind
+		}
+		return mid[a].near < mid[b].near
+	})
+	var edges []patchEdge
+	for row, v := range mid {
+		d := devices[v.pos]
+		nodes = append(nodes, patchNode{pos: v.pos, col: patchMiddle, row: row, label: patchLabel(d)})
+		n := len(nodes) - 1
+		switch d.pulsetype {
+		case pulseoutput:
+			if s := find(pulsesource, d.pulsesourceindex); s >= 0 {
+				edges = append(edges, patchEdge{s, n})
+			}
+		case pulsestream:
+			if p, ok := loopbackPartner(m, d); ok && d.pulsedriver == loopback_c {
+				if s := find(pulsesource, p.pulsesourceindex); s >= 0 {
+					edges = append(edges, patchEdge{s, n})
+				}
+			}
+			if s := find(pulsesink, d.pulsesinkindex); s >= 0 {
+				edges = append(edges, patchEdge{n, s})
+			}
+		}
+	}
+	return nodes, edges
+}
+
+// row of a node, -1 when the node is missing
+func nodeRow(nodes []patchNode, i int) int {
+	if i < 0 {
+		return -1
+	}
+	return nodes[i].row
+}
+
+// node of the device on cursor, the first node if it has none
+func patchCursor(m *model, nodes []patchNode) int {
+	for i, n := range nodes {
+		if n.pos == m.Cursor.pos {
+			return i
+		}
+	}
+	return 0
+}
+
+// open or close the patchbay
+func togglePatchbay(m *model) {
+	key := meterKey(m.Device[m.Cursor.pos])
+	m.Patchbay = !m.Patchbay
+	arrangeDevices(m)
+	followCursor(m, key)
+	if m.Patchbay {
+		m.Message = "patchbay: s selects, enter routes or unroutes"
+		return
+	}
+	m.Message = "patchbay closed"
+}
+
+// move the cursor through the graph, j/k in a column and h/l across columns
+func navigatePatchbay(m *model, msg tea.KeyMsg) bool {
+	nodes, _ := buildPatchbay(m)
+	if len(nodes) == 0 {
+		return false
+	}
+	cur := nodes[patchCursor(m, nodes)]
+	col, row := cur.col, cur.row
+	switch {
+	case key.Matches(msg, m.Keys.Up):
+		row--
+	case key.Matches(msg, m.Keys.Down):
+		row++
+	case key.Matches(msg, patchLeft):
+		col--
+	case key.Matches(msg, patchRight):
+		col++
+	default:
+		return false
+	}
+	next, best := -1, 0
+	for i, n := range nodes { // nearest box in the column moved to
+		dist := n.row - row
+		if dist < 0 {
+			dist = -dist
+		}
+		if n.col == col && (col != cur.col || n.row == row) && (next < 0 || dist < best) {
+			next, best = i, dist
+		}
+	}
+	if next >= 0 {
+		m.Cursor.pos = nodes[next].pos
+		scrollToCursor(m)
+	}
+	return true
+}
+
+// pair of device types a route joins, the device the other is routed to first
+func routeTarget(a, b int) int {
+	switch {
+	case a == pulsesink && (b == pulsestream || b == pulsesource),
+		b == pulsesink && (a == pulsestream || a == pulsesource):
+		return pulsesink
+	case a == pulsesource && b == pulseoutput, b == pulsesource && a == pulseoutput:
+		return pulsesource
+	}
+	return -1
+}
+
+// route the selected device and the device on cursor in either order: move a
+// stream or output, or load a loopback from a source to a sink, or unload it
+// when one already joins them
+func patchRoute(m *model) {
+	from := -1
+	for i, d := range m.Device[:m.Count.total-m.Count.cards] {
+		if d.pulsetype == m.Selected.devicetype && d.pulseindex == m.Selected.index {
+			from = i
+		}
+	}
+	to := m.Cursor.pos
+	if from < 0 || from == to { // nothing to route, keep the list behaviour
+		forEachStream(m, performAction)
+		return
+	}
+	target := routeTarget(m.Device[from].pulsetype, m.Device[to].pulsetype)
+	if target < 0 {
+		m.Message = "no route between these devices"
+		return
+	}
+	if m.Device[from].pulsetype != target {
+		from, to = to, from
+	}
+	if m.Device[to].pulsetype == pulsesource && unloadRoute(m, m.Device[to], m.Device[from]) {
+		return
+	}
+	cursor := m.Cursor.pos
+	m.Cursor.pos = from
+	selectDevice(m)
+	m.Cursor.pos = to
+	forEachStream(m, performAction)
+	m.Cursor.pos = cursor
+}
+
+// unload the loopbacks from a source to a sink, false if there are none
+func unloadRoute(m *model, source, sink PulseDevice) bool {
+	var modules []string
+	for _, d := range m.Server {
+		if d.pulsetype != pulsestream || d.pulsedriver != loopback_c || d.pulsesinkindex != sink.pulseindex {
+			continue
+		}
+		if p, ok := loopbackPartner(m, d); ok && p.pulsesourceindex == source.pulseindex {
+			modules = append(modules, d.pulsemodule)
+		}
+	}
+	if len(modules) == 0 {
+		return false
+	}
+	for _, module := range modules {
+		if err := exec.Command(pactl, unload_module, module).Run(); err != nil {
+			m.Message = fmt.Sprintf("error unloading module: #%v", module)
+			m.Err = err
+			return true
+		}
+	}
+	m.Message = fmt.Sprintf("loopback removed: %v to %v", source.pulsedescription, sink.pulsedescription)
+	resetSelected(m) // unset Selected after operation
+	return true
+}
+
+// lines and corners of boxes and routes
+type patchRunes struct {
+	h, v, tl, tr, bl, br, cross, arrow rune
+}
+
+func patchStyle(kind int) patchRunes {
+	tty := istty || setNoSymbol
+	switch {
+	case kind == 1 && tty: // cursor
+		return patchRunes{'=', '#', '#', '#', '#', '#', '+', '>'}
+	case kind == 2 && tty: // selected
+		return patchRunes{'~', '*', '*', '*', '*', '*', '+', '>'}
+	case tty:
+		return patchRunes{'-', '|', '+', '+', '+', '+', '+', '>'}
+	case kind == 1:
+		return patchRunes{'━', '┃', '┏', '┓', '┗', '┛', '┼', '▶'}
+	case kind == 2:
+		return patchRunes{'═', '║', '╔', '╗', '╚', '╝', '┼', '▶'}
+	}
+	return patchRunes{'─', '│', '┌', '┐', '└', '┘', '┼', '▶'}
+}
+
+// character grid the graph is drawn on
+type patchCanvas [][]rune
+
+func newPatchCanvas(width, height int) patchCanvas {
+	c := make(patchCanvas, height)
+	for y := range c {
+		c[y] = []rune(strings.Repeat(" ", width))
+	}
+	return c
+}
+
+// draw a route character, crossing routes meet in a cross
+func (c patchCanvas) line(x, y int, r rune) {
+	if y < 0 || y >= len(c) || x < 0 || x >= len(c[y]) {
+		return
+	}
+	if c[y][x] != ' ' && c[y][x] != r {
+		r = patchStyle(0).cross
+	}
+	c[y][x] = r
+}
+
+// draw a box with a label, covering any route under it
+func (c patchCanvas) box(x, y, width int, label string, s patchRunes) {
+	inner := []rune(cutText(label, width-2))
+	for i := 0; i < width; i++ {
+		top, bottom, mid := s.h, s.h, ' '
+		switch i {
+		case 0:
+			top, bottom, mid = s.tl, s.bl, s.v
+		case width - 1:
+			top, bottom, mid = s.tr, s.br, s.v
+		default:
+			if i-1 < len(inner) {
+				mid = inner[i-1]
+			}
+		}
+		c[y][x+i], c[y+1][x+i], c[y+2][x+i] = top, mid, bottom
+	}
+}
+
+// the patchbay drawn to fit the width of the device list
+func displayPatchbay(m *model) string {
+	nodes, edges := buildPatchbay(m)
+	if len(nodes) == 0 {
+		return ""
+	}
+	width := (m.StringLen - 2*patchGap) / 3
+	rows := 0
+	for _, n := range nodes {
+		if n.row+1 > rows {
+			rows = n.row + 1
+		}
+	}
+	c := newPatchCanvas(m.StringLen, rows*patchHeight-1)
+	x := func(n patchNode) int { return n.col * (width + patchGap) }
+	s := patchStyle(0)
+	for i, e := range edges {
+		a, b := nodes[e.from], nodes[e.to]
+		ya, yb := a.row*patchHeight+1, b.row*patchHeight+1
+		start, end := x(a)+width, x(b)-1
+		bend := start + 1 + i%(patchGap-3) // spread the vertical parts of routes
+		for i := start; i < bend; i++ {
+			c.line(i, ya, s.h)
+		}
+		for i := bend + 1; i < end; i++ {
+			c.line(i, yb, s.h)
+		}
+		switch {
+		case yb > ya:
+			c.line(bend, ya, s.tr)
+			c.line(bend, yb, s.bl)
+		case yb < ya:
+			c.line(bend, ya, s.br)
+			c.line(bend, yb, s.tl)
+		default:
+			c.line(bend, ya, s.h)
+		}
+		for y := ya + 1; y < yb; y++ {
+			c.line(bend, y, s.v)
+		}
+		for y := yb + 1; y < ya; y++ {
+			c.line(bend, y, s.v)
+		}
+		c.line(end, yb, s.arrow)
+	}
+	cursor := patchCursor(m, nodes)
+	for i, n := range nodes {
+		d := m.Device[n.pos]
+		kind := 0
+		if d.pulsetype == m.Selected.devicetype && d.pulseindex == m.Selected.index {
+			kind = 2
+		}
+		if i == cursor {
+			kind = 1
+		}
+		c.box(x(n), n.row*patchHeight, width, n.label, patchStyle(kind))
+	}
+	text := lipgloss.NewStyle().Foreground(toggleColor[1])
+	margin := strings.Repeat(" ", (m.Width-m.StringLen)/2) // center like the device list
+	var lines []string
+	for _, l := range c {
+		lines = append(lines, margin+text.Render(string(l)))
+	}
+	return strings.Join(lines, "\n") + "\n\n"
+}
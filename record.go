@@ -0,0 +1,289 @@
+// /////////////////////////////////////////////////////////////////////////////
+// RECORD SOURCES AND SINK MONITORS TO WAV
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/binary"                        // write wav header fields
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"io"                                     // copy sample stream to file
+	"math"                                   // largest wav file
+	"os"                                     // create recording files
+	"os/exec"                                // run external system commands
+	"path/filepath"                          // build recording file paths
+	"strconv"                                // convert types to/from string
+	"strings"                                // manipulate strings
+	"sync"                                   // guard running recordings
+	"time"                                   // name files and track elapsed time
+)
+
+const (
+	recordClient  = "pulsemanager-record" // client name of recording streams
+	wavHeaderSize = 44                    // canonical RIFF/WAVE header length
+	wavPCM        = 1                     // wav format tag for integer samples
+	wavFloat      = 3                     // wav format tag for ieee float samples
+	wavMaxData    = math.MaxUint32 - 36   // largest data chunk the riff size field holds
+)
+
+// sample specification parsed from pactl "s16le 2ch 44100Hz"
+type sampleSpec struct {
+	format   string // parec --format value
+	bits     int    // bits per sample written to wav
+	tag      int    // wav format tag
+	channels int
+	rate     int
+}
+
+// a parec process writing one device into a wav file
+type recording struct {
+	cmd      *exec.Cmd
+	path     string
+	started  time.Time
+	parts    int           // files written, a new one starts before wav size overflows
+	done     chan struct{} // closed once the header has been finalized
+	stopping bool          // interrupted, waiting for parec to exit
+}
+
+type RecordMsg struct { // a stopped recording has been saved
+	key string
+	rec *recording
+}
+
+var (
+	recordings = map[string]*recording{} // running recordings keyed by meterKey()
+	recordMux  sync.Mutex
+)
+
+// translate a pulseaudio sample specification into something wav can store
+func parseSampleSpec(s string) sampleSpec {
+	spec := sampleSpec{format: "s16le", bits: 16, tag: wavPCM, channels: 2, rate: 44100}
+	for _, v := range strings.Fields(s) {
+		switch {
+		case strings.HasSuffix(v, "ch"):
+			if n, err := strconv.Atoi(strings.TrimSuffix(v, "ch")); err == nil {
+				spec.channels = n
+			}
+		case strings.HasSuffix(v, "Hz"):
+			if n, err := strconv.Atoi(strings.TrimSuffix(v, "Hz")); err == nil {
+				spec.rate = n
+			}
+		}
+	}
+	format := ""
+	if f := strings.Fields(s); len(f) > 0 {
+		format = f[0]
+	}
+	switch format { // wav is little endian, ask parec to convert anything else
+	case "u8":
+		spec.format, spec.bits = "u8", 8
+	case "s24le", "s24be":
+		spec.format, spec.bits = "s24le", 24
+	case "s32le", "s32be", "s24-32le", "s24-32be":
+		spec.format, spec.bits = "s32le", 32
+	case "float32le", "float32be":
+		spec.format, spec.bits, spec.tag = "float32le", 32, wavFloat
+	}
+	return spec
+}
+
+// write a wav header for the given amount of sample data
+func writeWavHeader(w io.WriterAt, spec sampleSpec, data uint32) error {
+	align := spec.channels * spec.bits / 8
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+data)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], uint16(spec.tag))
+	binary.LittleEndian.PutUint16(h[22:], uint16(spec.channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(spec.rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(spec.rate*align))
+	binary.LittleEndian.PutUint16(h[32:], uint16(align))
+	binary.LittleEndian.PutUint16(h[34:], uint16(spec.bits))
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], data)
+	_, err := w.WriteAt(h, 0)
+	return err
+}
+
+// name of the pulseaudio source to record for a device
+func recordTarget(d PulseDevice) string {
+	switch d.pulsetype {
+	case pulsesink:
+		return d.pulsemonitor
+	case pulsesource:
+		return d.pulsename
+	}
+	return ""
+}
+
+// build a unique file name for a recording
+func recordPath(d PulseDevice) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, d.pulsename)
+	file := fmt.Sprintf("%v-%v.wav", name, time.Now().Format("20060102-150405"))
+	return filepath.Join(setRecordDir, file)
+}
+
+// name of a later part of a recording, name-2.wav, name-3.wav and so on
+func recordPart(path string, part int) string {
+	return fmt.Sprintf("%v-%v.wav", strings.TrimSuffix(path, ".wav"), part)
+}
+
+// start parec on a device and stream its samples into a wav file
+func startRecording(d PulseDevice) (*recording, error) {
+	target := recordTarget(d)
+	if target == "" {
+		return nil, fmt.Errorf("cannot record %v", getDeviceType(d.pulsetype))
+	}
+	if err := os.MkdirAll(setRecordDir, 0755); err != nil {
+		return nil, err
+	}
+	spec := parseSampleSpec(d.pulsesamplerate)
+	path := recordPath(d)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeWavHeader(file, spec, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.Seek(wavHeaderSize, io.SeekStart)
+	cmd := exec.Command(parec,
+		"--device="+target,
+		"--client-name="+recordClient,
+		"--raw",
+		"--format="+spec.format,
+		"--channels="+strconv.Itoa(spec.channels),
+		"--rate="+strconv.Itoa(spec.rate),
+	)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	r := &recording{cmd: cmd, path: path, started: time.Now(), parts: 1, done: make(chan struct{})}
+	go func() {
+		align := int64(spec.channels * spec.bits / 8)
+		limit := wavMaxData / align * align // whole frames only
+		for {
+			n, err := io.CopyN(file, out, limit)
+			writeWavHeader(file, spec, uint32(n))
+			file.Close()
+			if err != nil { // parec exited
+				break
+			}
+			r.parts++ // file is full, carry on in the next part
+			file, err = os.Create(recordPart(path, r.parts))
+			if err == nil {
+				err = writeWavHeader(file, spec, 0)
+			}
+			if err != nil {
+				if file != nil {
+					file.Close()
+				}
+				cmd.Process.Kill()
+				io.Copy(io.Discard, out)
+				break
+			}
+			file.Seek(wavHeaderSize, io.SeekStart)
+		}
+		cmd.Wait()
+		close(r.done)
+	}()
+	return r, nil
+}
+
+// ask parec to finish and wait for the wav header to be written
+func finishRecording(r *recording) {
+	r.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-r.done:
+	case <-time.After(2 * time.Second):
+		r.cmd.Process.Kill()
+		<-r.done
+	}
+}
+
+// stop a recording without blocking update, reported by a RecordMsg
+func stopRecording(key string, r *recording) tea.Cmd {
+	r.stopping = true
+	return func() tea.Msg {
+		finishRecording(r)
+		return RecordMsg{key, r}
+	}
+}
+
+// forget a saved recording and report its file
+func reportRecording(m *model, msg RecordMsg) {
+	recordMux.Lock()
+	defer recordMux.Unlock()
+	if recordings[msg.key] == msg.rec {
+		delete(recordings, msg.key)
+	}
+	m.Message = fmt.Sprintf("saved recording: %v", msg.rec.path)
+	if msg.rec.parts > 1 {
+		m.Message = fmt.Sprintf("saved recording: %v in %v parts", msg.rec.path, msg.rec.parts)
+	}
+}
+
+// finish every recording (called on exit)
+func stopRecordings() {
+	recordMux.Lock()
+	defer recordMux.Unlock()
+	for k, r := range recordings {
+		finishRecording(r)
+		delete(recordings, k)
+	}
+}
+
+// start or stop recording the source or sink monitor on cursor
+func toggleRecording(m *model) tea.Cmd {
+	recordMux.Lock()
+	defer recordMux.Unlock()
+	d := m.Device[m.Cursor.pos]
+	key := meterKey(d)
+	if r, ok := recordings[key]; ok {
+		if r.stopping {
+			m.Message = fmt.Sprintf("saving recording: %v", r.path)
+			return nil
+		}
+		m.Message = fmt.Sprintf("stopping recording: %v", d.pulsedescription)
+		return stopRecording(key, r)
+	}
+	if !haveProgram(parec) {
+		m.Message = "recording requires parec"
+		return nil
+	}
+	r, err := startRecording(d)
+	if err != nil {
+		m.Message = fmt.Sprintf("error recording: %v", err)
+		return nil
+	}
+	recordings[key] = r
+	m.Message = fmt.Sprintf("recording: %v", d.pulsedescription)
+	return nil
+}
+
+// elapsed time of a running recording, returns false if device is not recording
+func recordingElapsed(d PulseDevice) (time.Duration, bool) {
+	recordMux.Lock()
+	defer recordMux.Unlock()
+	r, ok := recordings[meterKey(d)]
+	if !ok {
+		return 0, false
+	}
+	return time.Since(r.started).Truncate(time.Second), true
+}
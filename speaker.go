@@ -0,0 +1,197 @@
+// /////////////////////////////////////////////////////////////////////////////
+// SPEAKER TEST TONES
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/binary"                        // encode samples for pacat
+	"fmt"                                    // format and print text
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"io"                                     // write sample stream
+	"math"                                   // generate tone
+	"math/rand"                              // generate noise
+	"os/exec"                                // run external system commands
+	"strconv"                                // convert types to/from string
+	"strings"                                // manipulate strings
+	"sync/atomic"                            // share active channel with generator
+	"time"                                   // step through channels
+)
+
+const (
+	pacat      = "pacat"             // playback client shipped with pactl
+	testClient = "pulsemanager-test" // client name of speaker test stream
+	testRate   = 48000               // sample rate of generated signal
+	testTone   = 440.0               // frequency of test tone in hertz
+	testGain   = 0.25                // keep test signal well below full scale
+	testFade   = testRate / 100      // samples to fade in after switching channel
+	testFrames = testRate / 50       // frames generated per write (20ms)
+	testNoise  = "noise"             // config value selecting pink noise
+	testSine   = "tone"              // config value selecting a sine tone
+)
+
+// state of a running speaker test
+type SpeakerTest struct {
+	active  bool
+	sink    int           // pulseindex of sink under test
+	channel int           // channel currently playing
+	count   int           // channels in sink channel map
+	id      int           // ignore TestMsg from a previous test
+	cmd     *exec.Cmd     // pacat process
+	current *atomic.Int32 // channel read by the generator goroutine
+}
+
+type TestMsg struct{ id int } // advance speaker test to the next channel
+func testCmd(id int) tea.Cmd {
+	return tea.Tick(time.Duration(setTestStep)*time.Second, func(t time.Time) tea.Msg {
+		return TestMsg{id}
+	})
+}
+
+var testCount = 0 // number of speaker tests started
+
+// generator for tone or pink noise samples
+type testSignal struct {
+	noise      bool
+	phase      float64
+	b0, b1, b2 float64 // pink noise filter state
+}
+
+// next sample between -1.0 and 1.0
+func (s *testSignal) next() float64 {
+	if !s.noise {
+		s.phase += 2 * math.Pi * testTone / testRate
+		if s.phase > 2*math.Pi {
+			s.phase -= 2 * math.Pi
+		}
+		return math.Sin(s.phase)
+	}
+	white := rand.Float64()*2 - 1 // paul kellet's economy pink noise filter
+	s.b0 = 0.99765*s.b0 + white*0.0990460
+	s.b1 = 0.96300*s.b1 + white*0.2965164
+	s.b2 = 0.57000*s.b2 + white*1.0526913
+	return (s.b0 + s.b1 + s.b2 + white*0.1848) * 0.2
+}
+
+// write the test signal into the active channel only until pacat exits
+func generateTest(w io.WriteCloser, channels int, current *atomic.Int32, noise bool) {
+	defer w.Close()
+	signal := testSignal{noise: noise}
+	buf := make([]byte, testFrames*channels*2)
+	last := int32(-1)
+	fade := 0
+	for {
+		active := current.Load()
+		if active != last { // fade in to avoid a click on every switch
+			last = active
+			fade = 0
+		}
+		for f := 0; f < testFrames; f++ {
+			gain := testGain
+			if fade < testFade {
+				gain *= float64(fade) / testFade
+				fade++
+			}
+			v := int16(signal.next() * gain * math.MaxInt16)
+			for c := 0; c < channels; c++ {
+				var sample int16
+				if int32(c) == active {
+					sample = v
+				}
+				binary.LittleEndian.PutUint16(buf[(f*channels+c)*2:], uint16(sample))
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return
+		}
+	}
+}
+
+// start playing the test signal on the sink at cursor
+func startSpeakerTest(m *model) {
+	d := m.Device[m.Cursor.pos]
+	if d.pulsetype != pulsesink {
+		m.Message = "speaker test requires a sink"
+		return
+	}
+	if !haveProgram(pacat) {
+		m.Message = "speaker test requires pacat"
+		return
+	}
+	cmd := exec.Command(pacat,
+		"--playback",
+		"--device="+d.pulsename,
+		"--client-name="+testClient,
+		"--raw",
+		"--format=s16le",
+		"--rate="+strconv.Itoa(testRate),
+		"--channels="+strconv.Itoa(d.pulsecount),
+		"--channel-map="+strings.Join(d.pulsechannels, ","),
+	)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		m.Message = "error starting speaker test"
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		m.Message = "error starting speaker test"
+		return
+	}
+	current := &atomic.Int32{}
+	go generateTest(in, d.pulsecount, current, setTestSignal == testNoise)
+	go cmd.Wait()
+	testCount++
+	m.Test = SpeakerTest{active: true, sink: d.pulseindex, count: d.pulsecount, id: testCount, cmd: cmd, current: current}
+	m.Message = fmt.Sprintf("speaker test: %v", d.pulsechannels[0])
+}
+
+// stop the test signal
+func stopSpeakerTest(m *model) {
+	if !m.Test.active {
+		return
+	}
+	m.Test.cmd.Process.Kill()
+	m.Test = SpeakerTest{}
+	m.Message = "speaker test stopped"
+}
+
+// start or stop a speaker test on the sink at cursor
+func toggleSpeakerTest(m *model) tea.Cmd {
+	if m.Test.active {
+		stopSpeakerTest(m)
+		return nil
+	}
+	startSpeakerTest(m)
+	if !m.Test.active {
+		return nil
+	}
+	return testCmd(m.Test.id)
+}
+
+// move the test signal to the next channel in the sink channel map
+func stepSpeakerTest(m *model, msg TestMsg) tea.Cmd {
+	if !m.Test.active || msg.id != m.Test.id {
+		return nil
+	}
+	m.Test.channel = (m.Test.channel + 1) % m.Test.count
+	m.Test.current.Store(int32(m.Test.channel))
+	for _, v := range m.Server {
+		if v.pulsetype != pulsesink || v.pulseindex != m.Test.sink {
+			continue
+		}
+		if len(v.pulsechannels) != m.Test.count || m.Test.channel >= len(v.pulsechannels) {
+			stopSpeakerTest(m) // channel map changed, e.g. by a profile switch
+			m.Message = "speaker test stopped: channel map changed"
+			return nil
+		}
+		m.Message = fmt.Sprintf("speaker test: %v", v.pulsechannels[m.Test.channel])
+		return testCmd(m.Test.id)
+	}
+	stopSpeakerTest(m) // sink was removed
+	return nil
+}
+
+// is this channel of a device currently playing the test signal
+func isTestChannel(m *model, d PulseDevice, channel int) bool {
+	return m.Test.active && d.pulsetype == pulsesink &&
+		d.pulseindex == m.Test.sink && m.Test.channel == channel
+}
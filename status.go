@@ -0,0 +1,146 @@
+// /////////////////////////////////////////////////////////////////////////////
+// STATUS BAR OUTPUT FOR WAYBAR, POLYBAR AND I3BLOCKS
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/json"                      // format waybar output
+	"fmt"                                // format and print text
+	flag "github.com/cornfeedhobo/pflag" // command line flag parsing
+	"math"                               // round volume
+	"strings"                            // manipulate strings
+)
+
+// supported status output formats
+const (
+	textFormat     = "text"
+	waybarFormat   = "waybar"
+	i3blocksFormat = "i3blocks"
+)
+
+// waybar custom module json (return-type: json)
+type waybarStatus struct {
+	Text       string `json:"text"`
+	Alt        string `json:"alt"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// find the default sink in a device list
+func defaultSink(d []PulseDevice) (PulseDevice, bool) {
+	name := getDefaultDevice(default_sink_rpl)
+	for _, v := range d {
+		if v.pulsetype == pulsesink && v.pulsename == name {
+			return v, true
+		}
+	}
+	return PulseDevice{}, false
+}
+
+// loudest channel of a device, the volume reported by pulseaudio
+func deviceVolume(d PulseDevice) int {
+	var max float64
+	for _, v := range d.pulsevolume {
+		max = math.Max(max, v)
+	}
+	return int(math.Round(max))
+}
+
+// volume icon of a device for status output
+func statusIcon(d PulseDevice) string {
+	icon := unmuted_icon
+	if d.pulsemute {
+		icon = muted_icon
+	}
+	if icon == "" { // symbols disabled
+		return icon
+	}
+	return strings.TrimSpace(icon) + " "
+}
+
+// render the default sink in the requested format
+func formatStatus(d PulseDevice, format string) string {
+	vol := deviceVolume(d)
+	battery := ""
+	if d.pulsebattery != "" {
+		if setNoSymbol {
+			battery = " " + d.pulsebattery
+		} else {
+			battery = " " + strings.TrimSpace(batteryIcon(d.pulsebattery, battery_icon)) + d.pulsebattery
+		}
+	}
+	text := fmt.Sprintf("%v%v%%%v", statusIcon(d), vol, battery)
+	if d.pulsemute && setNoSymbol {
+		text = fmt.Sprintf("muted %v%%%v", vol, battery)
+	}
+	switch format {
+	case waybarFormat:
+		class := "unmuted"
+		if d.pulsemute {
+			class = "muted"
+		}
+		out, _ := json.Marshal(waybarStatus{
+			Text:       text,
+			Alt:        class,
+			Tooltip:    fmt.Sprintf("%v\n%v", d.pulsedescription, d.pulseport),
+			Class:      class,
+			Percentage: vol,
+		})
+		return string(out)
+	case i3blocksFormat: // full_text and short_text lines
+		return fmt.Sprintf("%v\n%v%%", text, vol)
+	}
+	return text
+}
+
+// build the status line for the current default sink
+// i3blocks persistent blocks read one full_text line per update, so drop short_text
+func currentStatus(format string, follow bool) string {
+	d, _ := buildDevices(buildPulse())
+	sink, ok := defaultSink(d)
+	if !ok {
+		if format == waybarFormat {
+			return `{"text":"","class":"none"}`
+		}
+		return ""
+	}
+	status := formatStatus(sink, format)
+	if follow && format == i3blocksFormat {
+		status = strings.SplitN(status, "\n", 2)[0]
+	}
+	return status
+}
+
+// pulsemanager status [--format waybar|i3blocks|text] [--follow]
+func statusCommand(args []string) int {
+	var format string
+	var follow bool
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.StringVarP(&format, "format", "F", textFormat, "output format (waybar, i3blocks, text)")
+	fs.BoolVarP(&follow, "follow", "f", false, "print a new line on every change")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return commandUsage("status [--format waybar|i3blocks|text] [--follow]")
+	}
+	if format != textFormat && format != waybarFormat && format != i3blocksFormat {
+		return commandUsage("status [--format waybar|i3blocks|text] [--follow]")
+	}
+	last := currentStatus(format, follow)
+	fmt.Println(last)
+	if !follow {
+		return exitOK
+	}
+	subscribeEvents(func(event string) {
+		if !strings.Contains(event, " sink ") && !strings.Contains(event, " server") &&
+			!strings.Contains(event, " card ") {
+			return
+		}
+		status := currentStatus(format, follow)
+		if status == last {
+			return
+		}
+		last = status
+		fmt.Println(status)
+	})
+	return exitFailed // subscription ended, let the bar restart us
+}
@@ -6,8 +6,8 @@ package main
 import (
 	"github.com/charmbracelet/bubbles/help"      // manage help messages
 	"github.com/charmbracelet/bubbles/key"       // define application key map
-	"github.com/charmbracelet/bubbles/paginator" // split application results
 	"github.com/charmbracelet/bubbles/progress"  // render progress bars
+	"github.com/charmbracelet/bubbles/textinput" // volume entry prompt
 	tea "github.com/charmbracelet/bubbletea"     // main cli application library
 	"github.com/charmbracelet/lipgloss"          // style application
 	"strings"                                    // manipulate strings
@@ -57,9 +57,10 @@ var (
 
 // apply defined colors to lipgloss adaptive color settting
 var (
-	lip         = lipgloss.NewStyle()
-	toggleColor []lipgloss.AdaptiveColor
-	deviceColor []lipgloss.AdaptiveColor
+	lip          = lipgloss.NewStyle()
+	toggleColor  []lipgloss.AdaptiveColor
+	deviceColor  []lipgloss.AdaptiveColor
+	warningColor lipgloss.AdaptiveColor // volume above 100%
 )
 
 // text constants
@@ -87,7 +88,7 @@ var (
 const (
 	minConfigWidth     = 45  // program will exit if less than this value
 	maxConfigWidth     = 300 // maximum initial size (can be larger with winSizeMsg)
-	minConfigItems     = 1   // set to show at least one item (0 is crashable)
+	minConfigItems     = 0   // 0 shows every device that fits the terminal
 	maxConfigItems     = 12  // 12 is reasonable even at high resolution
 	minConfigVolume    = 50  // allows restriction on how high l will set volume
 	maxConfigVolume    = 180 // allows for boosting some streams if needed (bad for sinks)
@@ -95,6 +96,20 @@ const (
 	maxConfigIncrement = 30  // allows for large jumps using h/l
 	minConfigDisplay   = 1   // lowest initial display setting available
 	maxConfigDisplay   = 3   // highest intial display setting available
+	minConfigStep      = 1   // shortest speaker test time per channel in seconds
+	maxConfigStep      = 10  // longest speaker test time per channel in seconds
+	minConfigTimeout   = 1   // shortest hook run time in seconds
+	maxConfigTimeout   = 300 // longest hook run time in seconds
+	minConfigBattery   = 1   // lowest battery warning level in percent
+	maxConfigBattery   = 100 // highest battery warning level in percent
+	minConfigDecibel   = 1   // smallest decibel step
+	maxConfigDecibel   = 12  // largest decibel step
+	minConfigFade      = 1   // shortest fade in seconds
+	maxConfigFade      = 60  // longest fade in seconds
+	minConfigSleep     = 1   // shortest sleep timer in minutes
+	maxConfigSleep     = 720 // longest sleep timer in minutes
+	minConfigRate      = 1   // slowest peak meter frame rate
+	maxConfigRate      = 60  // fastest peak meter frame rate (parec latency floor)
 )
 
 // pulseaudio device enumeration
@@ -116,6 +131,7 @@ const (
 	sus_source_cmd     = "suspend-source"
 	move_stream_cmd    = "move-sink-input"
 	move_output_cmd    = "move-source-output"
+	card_profile_cmd   = "set-card-profile"
 	load_module        = "load-module"
 	unload_module      = "unload-module"
 	loopback_module    = "module-loopback"
@@ -127,6 +143,9 @@ const (
 	stream_mute_cmd    = "set-sink-input-mute"
 	source_mute_cmd    = "set-source-mute"
 	output_mute_cmd    = "set-source-output-mute"
+	default_sink_rpl   = "get-default-sink"
+	default_source_rpl = "get-default-source"
+	subscribe_cmd      = "subscribe"
 	sink_mute_rpl      = "get-sink-mute"
 	source_mute_rpl    = "get-source-mute"
 	running_state      = "RUNNING"
@@ -141,18 +160,27 @@ const (
 	incLatency         = 10  // latency adjustment amount
 )
 
-var varLatency = 10 // pass variable latency value to loopback
+var varLatency = 10 // starting loopback latency, each model keeps its own
 
 // tui icons set by isConsole() and setNoSymbols
 var (
-	muted_icon   = "󰖁  "
-	unmuted_icon = "󰕾  "
-	idle_icon    = "󰕿  "
-	sus_icon     = "󰝟  "
-	mic_icon     = "󰍬  "
-	pref_icon    = "󰁕  "
-	suff_icon    = "  󰁎"
-	battery_icon = map[int]string{90: " ", 80: " ", 70: " ", 60: " ",
+	muted_icon        = "󰖁  "
+	unmuted_icon      = "󰕾  "
+	idle_icon         = "󰕿  "
+	sus_icon          = "󰝟  "
+	mic_icon          = "󰍬  "
+	rec_icon          = "󰑊 "
+	lock_icon         = "󰌾 "
+	link_icon         = "󰌸 "
+	group_open_icon   = "▾ "
+	group_closed_icon = "▸ "
+	member_icon       = "· "
+	branch_icon       = "└ "
+	base_icon         = "│"
+	tick_icon         = "┊"
+	pref_icon         = "󰁕  "
+	suff_icon         = "  󰁎"
+	battery_icon      = map[int]string{90: " ", 80: " ", 70: " ", 60: " ",
 		50: " ", 40: " ", 30: " ", 20: " ", 10: " ", 0: ""}
 	bluetooth_battery_icon = map[int]string{90: "󰥆 ", 80: "󰥅 ", 70: "󰥄 ", 60: "󰥃 ",
 		50: "󰥂 ", 40: "󰥁 ", 30: "󰥀 ", 20: "󰤿 ", 10: "󰤾 ", 0: "󱃍 "}
@@ -174,6 +202,9 @@ type PulseDevice struct {
 	pulsecount       int              // number of channels (typically 2 for L/R)
 	pulsechannels    []string         // channel map strings (front-left, front-right)
 	pulsevolume      []float64        // value_percent for each channel
+	pulsevalue       []int            // raw volume for each channel (65536 is 100%)
+	pulsedb          []float64        // decibels for each channel, -Inf is silence
+	pulsebase        float64          // percent of the hardware 0 dB point, 0 if unknown
 	pulsebalance     float64          // -1.0 to 1.0, 0.0 is balanced
 	pulselatency     float64          // source output delay in microseconds
 	pulsecard        string           // name of the physical sound card
@@ -184,6 +215,18 @@ type PulseDevice struct {
 	pulsebus         string           // physical port used for sink/source (e.g. mic, line-in)
 	pulsebattery     string           // bluetooth battery level
 	pulsedevstring   string           // find bluetooth devices
+	pulsemonitor     string           // monitor source name of a sink
+	pulseapp         string           // application name of a stream
+	pulsemembers     []PulseDevice    // streams behind a group header
+	pulseprofile     string           // active profile name of a card
+	pulseprofiletext string           // active profile of a card as shown
+	pulseprofiles    []CardProfile    // available profiles of a card
+}
+
+// profile a card can be switched to
+type CardProfile struct {
+	name        string
+	description string
 }
 
 // track quantities of types in model
@@ -204,10 +247,25 @@ func tickCmd() tea.Cmd { // update program at set interval
 	})
 }
 
+type MeterMsg time.Time // redraw peak meters at setMeterRate frames per second
+func meterCmd() tea.Cmd {
+	return tea.Tick(time.Second/time.Duration(setMeterRate), func(t time.Time) tea.Msg {
+		return MeterMsg(t)
+	})
+}
+
 // data that must get sent at interval
 type RefreshMsg struct {
 	device []PulseDevice // pulseaudio devices
 	count  DeviceCount   // the number of each device type
+	def    Defaults      // default devices, empty if no events are watched
+	daemon int           // pid of a running daemon, 0 if none
+}
+
+// names of the default sink and source
+type Defaults struct {
+	sink   string
+	source string
 }
 
 // keep track of toggled device type and attributes
@@ -258,26 +316,53 @@ func initDisplay() Display {
 
 // main bubbletea model
 type model struct { // main bubbletea model
-	Device      []PulseDevice   // contains PulseDevice structs
-	Count       DeviceCount     // number of each type of device
-	Keys        programKeymap   // keymaps for program
-	Paginator   paginator.Model // manages pagination
-	Cursor      Cursor          // displayed position attributes
-	ChannelMode int             // control a specific channel
-	Width       int             // terminal width
-	Margin      int             // margin calculated using terminal width
-	Height      int             // terminal height
-	Help        help.Model      // manage help messages
-	Message     string          // show a helpful message on keypress
-	ShowMessage bool            // toggle messages on/off in view
-	Fullscreen  bool            // display program fullscreen
-	VolumeLimit float64         // limit volume increases
-	BarStyle    Bar             // how bar is styled with lipgloss
-	StringLen   int             // truncate strings outside of app width
-	Border      lipgloss.Style  // how application border is styled
-	Text        lipgloss.Style  // how application text is styled
-	Selected    SelectedDevice  // which device and what type is selected
-	Display     Display         // how much information to show for device
+	Device       []PulseDevice      // contains PulseDevice structs
+	Count        DeviceCount        // number of each type of device
+	Keys         programKeymap      // keymaps for program
+	Scroll       int                // first device shown in the list
+	Cursor       Cursor             // displayed position attributes
+	ChannelMode  int                // control a specific channel
+	Width        int                // terminal width
+	Margin       int                // margin calculated using terminal width
+	Height       int                // terminal height
+	Help         help.Model         // manage help messages
+	Message      string             // show a helpful message on keypress
+	ShowMessage  bool               // toggle messages on/off in view
+	Fullscreen   bool               // display program fullscreen
+	VolumeLimit  float64            // limit volume increases
+	Latency      int                // loopback latency in milliseconds
+	BarStyle     Bar                // how bar is styled with lipgloss
+	StringLen    int                // truncate strings outside of app width
+	Border       lipgloss.Style     // how application border is styled
+	Text         lipgloss.Style     // how application text is styled
+	Selected     SelectedDevice     // which device and what type is selected
+	Display      Display            // how much information to show for device
+	Meters       bool               // show live peak meters under bars
+	Peaks        map[string]float64 // latest peak level for each metered device
+	Test         SpeakerTest        // per-channel test signal on a sink
+	Err          error              // last pactl error, used for exit codes
+	Defaults     Defaults           // default devices at last refresh, for events
+	Daemon       int                // pid of a running daemon, 0 if none
+	Locks        []VolumeLock       // volume locks enforced on refresh
+	VolumeFormat string             // label bars in percent, db or both
+	DecibelSteps bool               // h/l change volume in decibels
+	Fade         Fade               // volume fade in progress
+	Sleep        Sleep              // sleep timer fading the default sink out
+	Balance      int                // h/l shift balance on this axis, 0 is off
+	Unlinked     map[string]bool    // devices whose channels keep their offsets
+	Entry        textinput.Model    // prompt for a typed volume
+	Entering     bool               // keys go to the volume prompt
+	Layout       bool               // speaker diagram under surround devices
+	Grouping     bool               // streams grouped by application
+	Collapsed    map[string]bool    // groups and tree branches whose streams are hidden
+	Tree         bool               // streams nested under sinks, outputs under sources
+	Server       []PulseDevice      // devices as read, before tree and group layout
+	ServerCount  DeviceCount        // number of each device type as read
+	Patchbay     bool               // routing graph shown instead of the list
+	Tab          int                // tab shown, tabAll lists every device
+	Tabs         [tabCount]TabState // cursor and scroll of each tab
+	CardPos      int                // card on cursor in the cards tab
+	Inspect      Inspector          // server properties of one device
 }
 
 // format progress bar by type, copy to pulsedevice
@@ -325,6 +410,27 @@ type programKeymap struct {
 	Volume100      key.Binding
 	LatencyUp      key.Binding
 	LatencyDown    key.Binding
+	ToggleMeters   key.Binding
+	Record         key.Binding
+	SpeakerTest    key.Binding
+	Lock           key.Binding
+	VolumeFormat   key.Binding
+	DecibelSteps   key.Binding
+	Fade           key.Binding
+	Sleep          key.Binding
+	Balance        key.Binding
+	Unlink         key.Binding
+	EnterVolume    key.Binding
+	Layout         key.Binding
+	Group          key.Binding
+	Collapse       key.Binding
+	Tree           key.Binding
+	Patchbay       key.Binding
+	NextTab        key.Binding
+	PrevTab        key.Binding
+	GoTab          key.Binding
+	Inspect        key.Binding
+	Copy           key.Binding
 	Demo           key.Binding
 }
 
@@ -463,6 +569,90 @@ func initKeymapping() *programKeymap {
 			key.WithKeys("-"),
 			key.WithHelp("-", "dec latency"),
 		),
+		ToggleMeters: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "peak meters"),
+		),
+		Record: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "record"),
+		),
+		SpeakerTest: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "speaker test"),
+		),
+		Lock: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "volume lock"),
+		),
+		VolumeFormat: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "percent/dB"),
+		),
+		DecibelSteps: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "dB steps"),
+		),
+		Fade: key.NewBinding(
+			key.WithKeys(strings.Split(fadeKeys, "")...),
+			key.WithHelp("!-)", "fade 10-100%"),
+		),
+		Sleep: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "sleep timer"),
+		),
+		Balance: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "balance"),
+		),
+		Unlink: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "unlink channels"),
+		),
+		EnterVolume: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "type volume"),
+		),
+		Layout: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "channel layout"),
+		),
+		Group: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "group streams"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "collapse group"),
+		),
+		Tree: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "tree view"),
+		),
+		Patchbay: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "patchbay"),
+		),
+		NextTab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "previous tab"),
+		),
+		GoTab: key.NewBinding(
+			key.WithKeys("alt+0", "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6"),
+			key.WithHelp("alt+0-6", "go to tab"),
+		),
+		Inspect: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "inspect device"),
+		),
+		Copy: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy property"),
+		),
 		Demo: key.NewBinding(
 			key.WithKeys("d"),
 		),
@@ -485,17 +675,6 @@ func (k programKeymap) FullHelp() [][]key.Binding {
 	}
 }
 
-// set paginator values and styles for model, takes item limit and total items
-func initPager(limit, total int) paginator.Model {
-	p := paginator.New()                                       // initialize new paginator
-	p.Type = paginator.Dots                                    // use dots or arabic numbers for page label
-	p.PerPage = limit                                          // maximum number of items on each page (flag)
-	p.SetTotalPages(total)                                     // calculate max pages based total/PerPage (len)
-	p.InactiveDot = lip.Foreground(toggleColor[0]).Render("•") // inactive dot view
-	p.ActiveDot = lip.Foreground(toggleColor[1]).Render("•")   // active dot view
-	return p
-}
-
 // set help model values and styles and send to bubbletea model
 func initHelp() help.Model {
 	style0 := lipgloss.NewStyle().Foreground(toggleColor[0])
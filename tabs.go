@@ -0,0 +1,275 @@
+// /////////////////////////////////////////////////////////////////////////////
+// TABS PER DEVICE TYPE, CARD PROFILES AND CONFIGURATION
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                                    // format and print text
+	"github.com/charmbracelet/bubbles/key"   // tab key bindings
+	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"github.com/charmbracelet/lipgloss"      // style tab headers
+	"github.com/spf13/viper"                 // name the configuration file
+	"os/exec"                                // run external system commands
+	"strconv"                                // convert types to/from string
+	"strings"                                // join tab headers
+)
+
+// tabs in the order tab cycles through them
+const (
+	tabAll = iota
+	tabPlayback
+	tabRecording
+	tabOutput
+	tabInput
+	tabCards
+	tabConfig
+	tabCount
+)
+
+var (
+	tabNames = []string{"All", "Playback", "Recording", "Output Devices", "Input Devices", "Cards", "Configuration"}
+	tabShort = []string{"All", "Play", "Rec", "Out", "In", "Cards", "Config"}
+)
+
+// cursor and scroll of a tab, kept while other tabs are shown
+type TabState struct {
+	pos    int
+	scroll int
+}
+
+// device type listed by a tab, -1 for tabs that list every type or none
+func tabType(tab int) int {
+	switch tab {
+	case tabPlayback:
+		return pulsestream
+	case tabRecording:
+		return pulseoutput
+	case tabOutput:
+		return pulsesink
+	case tabInput:
+		return pulsesource
+	}
+	return -1
+}
+
+// check whether a tab shows its own page instead of the device list
+func isPageTab(tab int) bool {
+	return tab == tabCards || tab == tabConfig
+}
+
+// keep the devices of the tab shown, cards stay at the end of the list
+func filterTab(m *model, d []PulseDevice, c DeviceCount) ([]PulseDevice, DeviceCount) {
+	t := tabType(m.Tab)
+	if t < 0 && !isPageTab(m.Tab) {
+		return d, c
+	}
+	var list []PulseDevice
+	for _, v := range d {
+		if v.pulsetype == t || v.pulsetype == pulsecard {
+			list = append(list, v)
+		}
+	}
+	n := DeviceCount{cards: c.cards}
+	switch t {
+	case pulsesink:
+		n.sinks = c.sinks
+	case pulsestream:
+		n.streams = c.streams
+	case pulsesource:
+		n.sources = c.sources
+	case pulseoutput:
+		n.outputs = c.outputs
+	}
+	n.total = len(list)
+	return list, n
+}
+
+// show another tab, restoring the cursor and scroll it had
+func switchTab(m *model, tab int) {
+	if tab == m.Tab {
+		return
+	}
+	m.Tabs[m.Tab] = TabState{m.Cursor.pos, m.Scroll}
+	m.Tab = tab
+	resetChannelMode(m)
+	arrangeDevices(m)
+	m.Cursor.pos, m.Scroll = m.Tabs[tab].pos, m.Tabs[tab].scroll
+	refreshPosition(m)
+	if m.Cursor.pos < 0 {
+		m.Cursor.pos = 0
+	}
+	m.Message = tabNames[tab]
+}
+
+// handle tab, shift+tab and alt+digit, false for other keys
+func updateTabKeys(m *model, msg tea.KeyMsg) bool {
+	switch {
+	case key.Matches(msg, m.Keys.NextTab):
+		switchTab(m, (m.Tab+1)%tabCount)
+	case key.Matches(msg, m.Keys.PrevTab):
+		switchTab(m, (m.Tab+tabCount-1)%tabCount)
+	case key.Matches(msg, m.Keys.GoTab):
+		tab, _ := strconv.Atoi(strings.TrimPrefix(msg.String(), "alt+"))
+		switchTab(m, tab)
+	default:
+		return false
+	}
+	return true
+}
+
+// keys of the cards and configuration pages and of empty tabs, true when the
+// key was used up and the list must not see it
+func updateTabPage(m *model, msg tea.KeyMsg) bool {
+	if m.Tab == tabCards && m.Count.cards > 0 {
+		switch {
+		case key.Matches(msg, m.Keys.Up):
+			m.CardPos = (m.CardPos + m.Count.cards - 1) % m.Count.cards
+		case key.Matches(msg, m.Keys.Down):
+			m.CardPos = (m.CardPos + 1) % m.Count.cards
+		case key.Matches(msg, m.Keys.VolumeUp, m.Keys.PerformAction):
+			changeProfile(m, 1)
+		case key.Matches(msg, m.Keys.VolumeDown):
+			changeProfile(m, -1)
+		}
+	}
+	return !key.Matches(msg, m.Keys.Quit, m.Keys.ShowFullHelp, m.Keys.ShowMessage, m.Keys.Fullscreen)
+}
+
+// card on the cards page cursor
+func cursorCard(m *model) (PulseDevice, bool) {
+	cards := m.Device[m.Count.total-m.Count.cards:]
+	if m.CardPos >= len(cards) {
+		m.CardPos = 0
+	}
+	if len(cards) == 0 {
+		return PulseDevice{}, false
+	}
+	return cards[m.CardPos], true
+}
+
+// switch the card on cursor to the next or previous available profile
+func changeProfile(m *model, step int) {
+	card, ok := cursorCard(m)
+	if !ok || len(card.pulseprofiles) == 0 {
+		m.Message = "card has no profiles"
+		return
+	}
+	next := 0
+	for i, p := range card.pulseprofiles {
+		if p.name == card.pulseprofile {
+			next = (i + step + len(card.pulseprofiles)) % len(card.pulseprofiles)
+		}
+	}
+	p := card.pulseprofiles[next]
+	if err := exec.Command(pactl, card_profile_cmd, strconv.Itoa(card.pulseindex), p.name).Run(); err != nil {
+		m.Message = fmt.Sprintf("error setting profile: %v", p.description)
+		m.Err = err
+		return
+	}
+	m.Message = fmt.Sprintf("%v: %v", card.pulsedescription, p.description)
+}
+
+// tab headers with the number of devices of each type
+func displayTabs(m *model) string {
+	count := []int{
+		m.ServerCount.total - m.ServerCount.cards,
+		m.ServerCount.streams,
+		m.ServerCount.outputs,
+		m.ServerCount.sinks,
+		m.ServerCount.sources,
+		m.ServerCount.cards,
+	}
+	sep := " │ "
+	if istty || setNoSymbol {
+		sep = " | "
+	}
+	header := func(names []string) []string {
+		var tabs []string
+		for i, name := range names {
+			if i < len(count) {
+				name = fmt.Sprintf("%v %v", name, count[i])
+			}
+			style := lipgloss.NewStyle().Foreground(toggleColor[0])
+			if i == m.Tab {
+				style = style.Foreground(toggleColor[1]).Underline(true)
+			}
+			tabs = append(tabs, style.Render(name))
+		}
+		return tabs
+	}
+	tabs := header(tabNames)
+	if lipgloss.Width(strings.Join(tabs, sep)) > m.StringLen { // narrow terminals
+		tabs = header(tabShort)
+	}
+	return strings.Join(tabs, sep)
+}
+
+// cards and their profiles, the one on cursor with every available profile
+func displayCards(m *model) string {
+	style := lip.Width(m.Width).Align(center)
+	cards := m.Device[m.Count.total-m.Count.cards:]
+	if len(cards) == 0 {
+		return style.Foreground(toggleColor[1]).Render("No Cards To Report") + "\n\n"
+	}
+	cursorCard(m) // keep the cursor on a card
+	var s string
+	for i, c := range cards {
+		title := cutText(fmt.Sprintf("%v · %v", c.pulsedescription, c.pulseprofiletext), m.StringLen)
+		if i != m.CardPos {
+			s += style.Foreground(toggleColor[0]).Render(title) + "\n\n"
+			continue
+		}
+		s += style.Foreground(toggleColor[1]).Render(pref_icon+title+suff_icon) + "\n"
+		s += style.Foreground(toggleColor[0]).Render(cutText(fmt.Sprintf("card #%v %v %v", c.pulseindex, c.pulsename, c.pulsedriver), m.StringLen)) + "\n"
+		for _, p := range c.pulseprofiles {
+			mark := "  "
+			if p.name == c.pulseprofile {
+				mark = "* "
+			}
+			s += style.Foreground(toggleColor[1]).Render(cutText(mark+p.description, m.StringLen)) + "\n"
+		}
+		s += "\n"
+	}
+	return s
+}
+
+// settings in effect, set in the configuration file or by flags
+func displayConfig(m *model) string {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		file = "none, using defaults"
+	}
+	settings := [][]string{
+		{"Config", file},
+		{"VolumeLimit", fmt.Sprint(setMaxVolume)},
+		{"VolumeSteps", fmt.Sprint(setVolume)},
+		{"Items", fmt.Sprint(setItems)},
+		{"Width", fmt.Sprint(setWidth)},
+		{"DeviceDisplay", fmt.Sprint(setDisplay)},
+		{"VolumeFormat", setVolumeFormat},
+		{"DecibelSteps", fmt.Sprint(setDecibelStep)},
+		{"FadeTime", fmt.Sprintf("%vs", setFadeTime)},
+		{"SleepTimer", fmt.Sprintf("%vm", setSleepTimer)},
+		{"Meters", fmt.Sprint(setMeters)},
+		{"MeterRate", fmt.Sprint(setMeterRate)},
+		{"RecordDir", setRecordDir},
+		{"TestSignal", setTestSignal},
+		{"TestStep", fmt.Sprintf("%vs", setTestStep)},
+		{"HTTPAddr", setHTTPAddr},
+		{"MetricsAddr", setMetricsAddr},
+		{"Notify", setNotify},
+		{"Hooks", fmt.Sprint(len(setHooks))},
+		{"Locks", fmt.Sprint(len(setLocks))},
+	}
+	key := lipgloss.NewStyle().Foreground(toggleColor[0])
+	value := lipgloss.NewStyle().Foreground(toggleColor[1])
+	var lines []string
+	for _, v := range settings {
+		if v[1] == "" {
+			v[1] = "-"
+		}
+		lines = append(lines, key.Render(fmt.Sprintf("%-14v", v[0]))+value.Render(cutText(v[1], m.StringLen-14)))
+	}
+	block := lipgloss.NewStyle().Width(m.StringLen).Render(strings.Join(lines, "\n"))
+	return lipgloss.PlaceHorizontal(m.Width, lipgloss.Center, block) + "\n\n"
+}
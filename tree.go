@@ -0,0 +1,167 @@
+// /////////////////////////////////////////////////////////////////////////////
+// TREE VIEW OF STREAMS UNDER SINKS AND OUTPUTS UNDER SOURCES
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt" // format and print text
+)
+
+// check whether a device has streams or outputs nested under it in the tree
+func isBranch(d PulseDevice) bool {
+	return d.pulsetype == pulsesink || d.pulsetype == pulsesource
+}
+
+// check whether a stream plays into, or an output records from, a device
+func isChild(parent, d PulseDevice) bool {
+	switch {
+	case parent.pulsetype == pulsesink && d.pulsetype == pulsestream:
+		return d.pulsesinkindex == parent.pulseindex
+	case parent.pulsetype == pulsesource && d.pulsetype == pulseoutput:
+		return d.pulsesourceindex == parent.pulseindex
+	}
+	return false
+}
+
+// number of streams or outputs attached to a sink or source
+func countChildren(m *model, parent PulseDevice) int {
+	var n int
+	for _, d := range m.Server {
+		if isChild(parent, d) {
+			n++
+		}
+	}
+	return n
+}
+
+// order devices as a tree: each sink followed by its streams and each source by
+// its outputs, streams and outputs without a listed parent after the last one
+func treeDevices(m *model, d []PulseDevice, c DeviceCount) ([]PulseDevice, DeviceCount) {
+	if !m.Tree {
+		return d, c
+	}
+	var tree, cards []PulseDevice
+	placed := map[string]bool{}
+	nest := func(parent, child int) {
+		for _, p := range d {
+			if p.pulsetype != parent {
+				continue
+			}
+			tree = append(tree, p)
+			for _, s := range d {
+				if isChild(p, s) {
+					placed[meterKey(s)] = true
+					if !m.Collapsed[meterKey(p)] {
+						tree = append(tree, s)
+					}
+				}
+			}
+		}
+		for _, s := range d { // parent unknown or not listed yet
+			if s.pulsetype == child && !placed[meterKey(s)] {
+				tree = append(tree, s)
+			}
+		}
+	}
+	nest(pulsesink, pulsestream)
+	nest(pulsesource, pulseoutput)
+	for _, v := range d {
+		if v.pulsetype == pulsecard {
+			cards = append(cards, v)
+		}
+	}
+	c.streams, c.outputs = 0, 0
+	for _, v := range tree {
+		switch v.pulsetype {
+		case pulsestream:
+			c.streams++
+		case pulseoutput:
+			c.outputs++
+		}
+	}
+	tree = append(tree, cards...)
+	c.total = len(tree)
+	return tree, c
+}
+
+// lay out the devices read from the server as tree, stream groups and tab ask,
+// the patchbay draws its own routes and needs every device ungrouped, unfolded
+// and on every device tab
+func arrangeDevices(m *model) {
+	d, c := m.Server, m.ServerCount
+	if !m.Patchbay {
+		d, c = treeDevices(m, d, c)
+		d, c = groupStreams(m, d, c)
+	}
+	if !m.Patchbay || isPageTab(m.Tab) {
+		d, c = filterTab(m, d, c)
+	}
+	m.Device, m.Count = d, c
+	refreshPosition(m)
+}
+
+// keep the cursor on a device after the layout changed, if it is still shown
+func followCursor(m *model, key string) {
+	for i, d := range m.Device[:m.Count.total-m.Count.cards] {
+		if meterKey(d) == key {
+			m.Cursor.pos = i
+			scrollToCursor(m)
+			return
+		}
+	}
+}
+
+// turn the tree view on or off
+func toggleTree(m *model) {
+	key := meterKey(m.Device[m.Cursor.pos])
+	m.Tree = !m.Tree
+	arrangeDevices(m)
+	followCursor(m, key)
+	if m.Tree {
+		m.Message = "tree view on"
+		return
+	}
+	m.Message = "tree view off"
+}
+
+// fold or unfold the streams of the sink, or outputs of the source, on cursor
+func toggleBranch(m *model) {
+	d := m.Device[m.Cursor.pos]
+	key := meterKey(d)
+	if countChildren(m, d) == 0 {
+		m.Message = fmt.Sprintf("nothing attached: %v", d.pulsedescription)
+		return
+	}
+	m.Collapsed[key] = !m.Collapsed[key]
+	if !m.Collapsed[key] {
+		delete(m.Collapsed, key)
+	}
+	arrangeDevices(m)
+	followCursor(m, key)
+	m.Message = fmt.Sprintf("%v expanded", d.pulsedescription)
+	if m.Collapsed[key] {
+		m.Message = fmt.Sprintf("%v collapsed", d.pulsedescription)
+	}
+}
+
+// fold marker of a sink or source, or branch in front of a nested stream or output
+func displayTree(m *model, d PulseDevice) string {
+	if !m.Tree {
+		return ""
+	}
+	if isBranch(d) {
+		switch {
+		case countChildren(m, d) == 0:
+			return ""
+		case m.Collapsed[meterKey(d)]:
+			return group_closed_icon
+		}
+		return group_open_icon
+	}
+	for _, p := range m.Device {
+		if isChild(p, d) {
+			return branch_icon
+		}
+	}
+	return ""
+}
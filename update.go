@@ -7,6 +7,7 @@ import (
 	"fmt"                                    // format and print text
 	"github.com/charmbracelet/bubbles/key"   // define application key map
 	tea "github.com/charmbracelet/bubbletea" // main cli application library
+	"strings"                                // join lock corrections
 )
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -20,16 +21,71 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		cmds = append(cmds, tickCmd())
 		return m, tea.Batch(cmds...)
+	case MeterMsg:
+		if !m.Meters {
+			return m, nil
+		}
+		syncMeters(&m)
+		return m, meterCmd()
+	case TestMsg:
+		return m, stepSpeakerTest(&m, msg)
+	case FadeMsg:
+		return m, stepFade(&m, msg)
+	case SleepMsg:
+		return m, startSleepFade(&m, msg)
+	case ControlMsg:
+		return m, handleControl(&m, msg)
+	case HookMsg:
+		reportHook(&m, msg)
+	case NotifyMsg:
+		reportNotify(&m, msg)
+	case RecordMsg:
+		reportRecording(&m, msg)
 	case RefreshMsg:
-		m.Device = msg.device
-		m.Count = msg.count
-		refreshPosition(&m)
+		events := diffDevices(m.Server, msg.device, m.Defaults, msg.def)
+		locks := m.Locks
+		if msg.daemon != 0 { // a running daemon already enforces the config locks
+			locks = keyLocks(m.Locks)
+		}
+		if fixed := enforceLocks(locks, msg.device); len(fixed) > 0 {
+			m.Message = strings.Join(fixed, ", ")
+		}
+		m.Server, m.ServerCount = msg.device, msg.count
+		m.Defaults = msg.def
+		m.Daemon = msg.daemon
+		arrangeDevices(&m)
+		if m.Inspect.active {
+			refreshInspector(&m)
+		}
+		if m.Daemon == 0 { // a running daemon already handles events
+			cmd = tea.Batch(hookCmds(events), notifyCmds(events))
+		}
 	////////////////// WINDOW RESIZE ///////////////
 	case tea.WindowSizeMsg:
 		resizeProgram(&m, msg)
 		return m, validateTerminalSize(&m, msg)
 	////////////////// KEYSTROKES //////////////////
 	case tea.KeyMsg:
+		if m.Entering { // typing a volume
+			return m, updateEntry(&m, msg)
+		}
+		if m.Inspect.active { // the inspector takes the keys while open
+			if used, cmd := updateInspector(&m, msg); used {
+				return m, cmd
+			}
+		}
+		if updateTabKeys(&m, msg) {
+			return m, nil
+		}
+		if (isPageTab(m.Tab) || m.Count.total-m.Count.cards == 0) && updateTabPage(&m, msg) {
+			return m, nil // nothing on the list to act on
+		}
+		if m.Patchbay && navigatePatchbay(&m, msg) { // hjkl move through the graph
+			return m, nil
+		}
+		if isVolumeKey(m.Keys, msg) { // manual volume changes stop a fade
+			cancelFade(&m)
+		}
 		switch {
 		//////////////// NAVIGATION //////////////////
 		case key.Matches(msg, m.Keys.Up):
@@ -56,8 +112,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			resetOptions(&m)
 			return m, updateDevices(m)
 		//////////////// COMMANDS ////////////////////
+		case key.Matches(msg, m.Keys.PerformAction) && m.Patchbay:
+			patchRoute(&m)
 		case key.Matches(msg, m.Keys.PerformAction):
-			performAction(&m)
+			forEachStream(&m, performAction)
 		case key.Matches(msg, m.Keys.ChangeDisplay):
 			changeDisplayLevel(&m)
 			return m, updateDevices(m)
@@ -67,7 +125,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.Keys.SelectDevice):
 			selectDevice(&m)
 		case key.Matches(msg, m.Keys.KillStream):
-			killStreamOrOutput(&m)
+			forEachStream(&m, killStreamOrOutput)
 		case key.Matches(msg, m.Keys.UnloadLoopback):
 			unloadLoopback(&m)
 		case key.Matches(msg, m.Keys.LatencyUp):
@@ -79,7 +137,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return toggleFullscreen(&m)
 		case key.Matches(msg, m.Keys.ShowMessage):
 			showMessages(&m)
+		case key.Matches(msg, m.Keys.ToggleMeters):
+			return m, toggleMeters(&m)
+		case key.Matches(msg, m.Keys.Record):
+			return m, toggleRecording(&m)
+		case key.Matches(msg, m.Keys.SpeakerTest):
+			return m, toggleSpeakerTest(&m)
+		case key.Matches(msg, m.Keys.Lock):
+			toggleLock(&m)
+		case key.Matches(msg, m.Keys.VolumeFormat):
+			changeVolumeFormat(&m)
+		case key.Matches(msg, m.Keys.DecibelSteps):
+			toggleDecibelSteps(&m)
+		case key.Matches(msg, m.Keys.Fade):
+			return m, fadeDevice(&m, msg.String())
+		case key.Matches(msg, m.Keys.Sleep):
+			return m, toggleSleep(&m)
+		case key.Matches(msg, m.Keys.Balance):
+			toggleBalance(&m)
+			return m, updateDevices(m)
+		case key.Matches(msg, m.Keys.Unlink):
+			toggleLink(&m)
+		case key.Matches(msg, m.Keys.EnterVolume):
+			return m, startEntry(&m)
+		case key.Matches(msg, m.Keys.Layout):
+			toggleLayout(&m)
+		case key.Matches(msg, m.Keys.Group):
+			toggleGrouping(&m)
+		case key.Matches(msg, m.Keys.Tree):
+			toggleTree(&m)
+		case key.Matches(msg, m.Keys.Patchbay):
+			togglePatchbay(&m)
+		case key.Matches(msg, m.Keys.Inspect):
+			openInspector(&m)
+		case key.Matches(msg, m.Keys.Collapse):
+			toggleCollapse(&m)
 		case key.Matches(msg, m.Keys.Quit):
+			stopSpeakerTest(&m)
 			return m, tea.Quit
 		case key.Matches(msg, m.Keys.ShowFullHelp):
 			m.Help.ShowAll = !m.Help.ShowAll
@@ -87,58 +181,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// displayProgramMessage(&m)
 		//////////////// VOLUME //////////////////////
 		case key.Matches(msg, m.Keys.Mute):
-			toggleDeviceMute(&m)
+			toggleMute(&m)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.VolumeUp):
-			changeDeviceVolume(&m, formatDeviceVolume(&m, true))
+			forEachStream(&m, func(m *model) { changeDeviceVolume(m, formatKeyVolume(m, true)) })
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.VolumeDown):
-			changeDeviceVolume(&m, formatDeviceVolume(&m, false))
+			forEachStream(&m, func(m *model) { changeDeviceVolume(m, formatKeyVolume(m, false)) })
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume10):
-			normalizeDeviceVolume(&m, 10)
+			presetVolume(&m, 10)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume20):
-			normalizeDeviceVolume(&m, 20)
+			presetVolume(&m, 20)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume30):
-			normalizeDeviceVolume(&m, 30)
+			presetVolume(&m, 30)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume40):
-			normalizeDeviceVolume(&m, 40)
+			presetVolume(&m, 40)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume50):
-			normalizeDeviceVolume(&m, 50)
+			presetVolume(&m, 50)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume60):
-			normalizeDeviceVolume(&m, 60)
+			presetVolume(&m, 60)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume70):
-			normalizeDeviceVolume(&m, 70)
+			presetVolume(&m, 70)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume80):
-			normalizeDeviceVolume(&m, 80)
+			presetVolume(&m, 80)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume90):
-			normalizeDeviceVolume(&m, 90)
+			presetVolume(&m, 90)
 			return m, updateDevices(m)
 		case key.Matches(msg, m.Keys.Volume100):
-			normalizeDeviceVolume(&m, 100)
+			presetVolume(&m, 100)
 			return m, updateDevices(m)
 		}
+	default:
+		if m.Entering { // cursor blink of the volume prompt
+			m.Entry, cmd = m.Entry.Update(msg)
+		}
 	}
 	return m, cmd
 }
 
-// helper changes cursor to the first device on page when jumping by page
-func setCursor(m *model) int {
-	cursor := (m.Paginator.Page * m.Paginator.PerPage)
-	return cursor
+// helper checks for keys that change volume or mute
+func isVolumeKey(k programKeymap, msg tea.KeyMsg) bool {
+	return key.Matches(msg, k.Mute, k.VolumeUp, k.VolumeDown, k.Fade,
+		k.Volume10, k.Volume20, k.Volume30, k.Volume40, k.Volume50,
+		k.Volume60, k.Volume70, k.Volume80, k.Volume90, k.Volume100)
 }
 
 // helper sets channel mode to -1/default
 func resetChannelMode(m *model) {
 	m.ChannelMode = -1
+	m.Balance = balanceOff
 }
 func resetSelected(m *model) {
 	m.Selected.devicetype = -1
@@ -170,12 +270,7 @@ func cursorUp(m *model, msg tea.Msg) {
 		m.Cursor.pos--
 		clearMessages(m)
 	}
-	if m.Cursor.pos < (m.Paginator.Page * m.Paginator.PerPage) {
-		m.Paginator.PrevPage()
-		m.Cursor.pos = (m.Paginator.Page*m.Paginator.PerPage + (m.Paginator.PerPage - 1))
-		return
-	}
-	return
+	scrollToCursor(m)
 }
 
 // move down one device
@@ -185,51 +280,51 @@ func cursorDown(m *model, msg tea.Msg) {
 		m.Cursor.pos++
 		clearMessages(m)
 	}
-	if m.Cursor.pos >= ((m.Paginator.Page + 1) * m.Paginator.PerPage) {
-		m.Paginator.NextPage()
-		m.Cursor.pos = (m.Paginator.Page * m.Paginator.PerPage)
-		return
-	}
-	return
+	scrollToCursor(m)
 }
 
 // go to first device
 func cursorFirst(m *model) {
 	resetChannelMode(m)
-	m.Paginator.Page = 0
 	m.Message = fmt.Sprintf("go to first device")
 	m.Cursor.pos = 0
-	return
+	scrollToCursor(m)
 }
 
 // go to last device
 func cursorLast(m *model) {
 	resetChannelMode(m)
-	m.Paginator.Page = m.Paginator.TotalPages - 1
 	m.Message = fmt.Sprintf("go to last device")
 	m.Cursor.pos = (m.Count.total - m.Count.cards) - 1 // exclude cards
-	return
+	scrollToCursor(m)
 }
+
+// scroll by the devices that fit the terminal, the cursor on the first one shown
 func cursorPage(m *model, next bool) {
 	resetChannelMode(m)
+	start, end := visibleRange(m)
 	if next {
-		m.Paginator.NextPage()
+		m.Cursor.pos = end
+		if last := (m.Count.total - m.Count.cards) - 1; m.Cursor.pos > last {
+			m.Cursor.pos = last
+		}
+		m.Scroll = m.Cursor.pos
 		m.Message = "next page"
 	} else {
-		m.Paginator.PrevPage()
+		m.Cursor.pos = start - (end - start)
+		if m.Cursor.pos < 0 {
+			m.Cursor.pos = 0
+		}
+		m.Scroll = m.Cursor.pos
 		m.Message = "prev page"
 	}
-	m.Cursor.pos = (m.Paginator.Page * m.Paginator.PerPage)
-	return
+	scrollToCursor(m)
 }
 
 // iterate through channel control
 func changeChannel(m *model) {
-	if m.ChannelMode < m.Device[m.Cursor.pos].pulsecount-1 {
-		m.ChannelMode++
-	} else {
-		m.ChannelMode = -1
-	}
+	m.Balance = balanceOff // single channels have no balance
+	m.ChannelMode = nextChannel(m.Device[m.Cursor.pos], m.ChannelMode)
 	if m.ChannelMode < 0 { // don't access a negative index
 		m.Message = fmt.Sprintf("all channels selected balance: %v", m.Device[m.Cursor.pos].pulsebalance)
 		return
@@ -255,7 +350,7 @@ func toggleFullscreen(m *model) (tea.Model, tea.Cmd) {
 func resizeProgram(m *model, msg tea.WindowSizeMsg) {
 	// pass model new terminal size attributes
 	m.Width = msg.Width - 4 // set app width a little less than terminal width
-	m.Height = msg.Height   // TODO may need in the future (only includes app height)
+	m.Height = msg.Height   // fit the device list to the terminal height
 	// center the application
 	if m.Width > setWidth { // fill the margin with empty space if terminal
 		m.Width = setWidth // is larger than the configured app width and
@@ -276,23 +371,18 @@ func resizeProgram(m *model, msg tea.WindowSizeMsg) {
 		MarginRight(m.Margin)
 	// resize help model based on new width
 	m.Help.Width = m.Width
+	// keep the cursor in the list after the height changed
+	scrollToCursor(m)
 }
 
 // handle removal of devices
 func refreshPosition(m *model) {
-	// m.Paginator.SetTotalPages(len(m.Device))
-	m.Paginator.SetTotalPages(m.Count.total - m.Count.cards)
-	// handle removal of devices >> reduction in pages
-	for m.Paginator.Page > m.Paginator.TotalPages-1 {
-		m.Paginator.Page -= 1
-		if m.Paginator.Page == 0 {
-			break
-		}
-	}
 	// set cursor to last entry if it is set past last actual entry
 	if m.Cursor.pos > ((m.Count.total - m.Count.cards) - 1) {
 		m.Cursor.pos = ((m.Count.total - m.Count.cards) - 1)
 	}
+	// handle removal of devices >> scroll back to fill the list
+	scrollToCursor(m)
 }
 
 // handle minimum terminal size
@@ -7,6 +7,7 @@ import (
 	"fmt"                                       // format and print text
 	"github.com/charmbracelet/bubbles/progress" // render progress bars
 	"github.com/charmbracelet/lipgloss"         // style application
+	"math"                                      // scale peak meters
 	"strconv"                                   // convert types to/from string
 	"strings"                                   // manipulate strings
 )
@@ -26,45 +27,73 @@ func (m model) View() string {
 	////////////////////////////////////////////////////////////////////////////////
 	// Logic Required To Produce View
 	// We don't want to show cards in view, so use a slice without them
-	// then get slice range for current page and iterate through/display below
+	// then get the devices that fit the terminal and iterate through/display below
 	DevicesExcludingCards := m.Device[:m.Count.total-m.Count.cards]
-	start, end := m.Paginator.GetSliceBounds(len(DevicesExcludingCards))
-	// when multiple elements are rendered on the same line, their widths need to
-	// account for one another (in this instance its paginator text and message text)
-	// also account for padding that elements use (padding at line start and end)
-	paginatorLen := m.Paginator.TotalPages + (len(pad) * 2)
-	// paginatorLen := m.Paginator.TotalPages
-	pWidth := (m.Width - paginatorLen)
-	// update help model text rendering according to the latest width update
-	// width set in update function
-	helpText := m.Help.View(m.Keys)
+	start, end := visibleRange(&m)
 	////////////////////////////////////////////////////////////////////////////////
 	// exit view if there are no devices to report
-	if len(DevicesExcludingCards) < 1 { // change value to debug
+	if len(DevicesExcludingCards) < 1 && m.Tab == tabAll { // change value to debug
 		return m.Border.Render(displayEmptyList(&m))
 	}
 	// Rendered View String
 	////////////////////////////////////////////////////////////////////////////////
-	s := ""
-	if !setNoTitle { // program header can be toggled off in config
-		s += m.Text.Render(fmt.Sprintf("%v", programName)) // one line
-		s += "\n"                                          // one line
-	}
-	// TODO use/remove debug strings
-	// s += pad + m.Text.UnsetAlign().Render(fmt.Sprintf("m.Display.level: %v", m.Display.level))
-
-	// show current selected device if any // one line
-	s += pad + m.Text.UnsetAlign().Render(cutText(displayToggledDevice(&m), m.StringLen / 2))
-	s += "\n\n" // two lines
+	s := displayHeader(&m)
 	// loop through each device and add its channel info to the view string
+	switch {
+	case m.Tab == tabCards:
+		s += displayCards(&m)
+	case m.Tab == tabConfig:
+		s += displayConfig(&m)
+	case m.Inspect.active:
+		s += displayInspector(&m)
+		start, end = 0, 0
+	case len(DevicesExcludingCards) < 1:
+		s += m.Text.Render("No Devices To Report") + "\n\n"
+	case m.Patchbay: // or draw the routing graph in its place
+		s += displayPatchbay(&m)
+		start, end = 0, 0
+	}
 	for index, pulsedevice := range DevicesExcludingCards[start:end] {
 		s += displayEntry(&m, pulsedevice, index)
 	}
-	s += pad + fmt.Sprintf("%v", m.Paginator.View()) // paginator counter +
-	if m.ShowMessage {                               // messages rendered on the same line
+	s += displayFooter(&m, displayPosition(&m, start, end))
+	return m.Border.Render(s) // return view inside border
+}
+
+// functions used by view()
+// //////////////////////////////////////////////////////////////////////////////
+// helper function renders the title, tabs and toggled device above the devices
+func displayHeader(m *model) string {
+	var s string
+	if !setNoTitle { // program header can be toggled off in config
+		s += m.Text.Render(displayTitle(m)) // one line
+		s += "\n"                           // one line
+	}
+	s += m.Text.Render(displayTabs(m)) + "\n" // one line
+	// show current selected device if any // one line
+	s += pad + m.Text.UnsetAlign().Render(cutText(displayToggledDevice(m), m.StringLen/2))
+	s += "\n\n" // two lines
+	return s
+}
+
+// helper function renders the scroll position, messages and help below the devices
+func displayFooter(m *model, position string) string {
+	// when multiple elements are rendered on the same line, their widths need to
+	// account for one another (in this instance its position text and message text)
+	// also account for padding that elements use (padding at line start and end)
+	positionLen := lipgloss.Width(position) + (len(pad) * 2)
+	pWidth := (m.Width - positionLen)
+	// update help model text rendering according to the latest width update
+	// width set in update function
+	helpText := m.Help.View(m.Keys)
+	s := pad + position // scroll position +
+	if m.Entering {     // volume prompt takes the message line
+		m.Text.UnsetForeground()
+		m.Text.Foreground(toggleColor[1])
+		s += m.Text.Width(pWidth).Align(right).Render(m.Entry.View())
+	} else if m.ShowMessage { // messages rendered on the same line
 		m.Text.UnsetForeground()
 		m.Text.Foreground(toggleColor[1])
-		// s += m.Text.Width(pWidth).Align(center).Render(cutText(fmt.Sprintf("%v", m.Message), m.StringLen))
 		s += m.Text.Width(pWidth).Align(right).Render(cutText(fmt.Sprintf("%v", m.Message), m.StringLen))
 	}
 	s += "\n\n"     // two lines
@@ -72,11 +101,20 @@ func (m model) View() string {
 		// help module (include a newline while rending please)
 		s += m.Text.Align(center).Width(m.Width).Render(helpText + "\n") // 1 or 3 lines
 	}
-	return m.Border.Render(s) // return view inside border
+	return s
+}
+
+// helper function shows the program name and whether a daemon handles events
+func displayTitle(m *model) string {
+	switch {
+	case m.Daemon > 0:
+		return fmt.Sprintf("%v · daemon %v%v", programName, m.Daemon, displaySleep(m))
+	case m.Daemon < 0:
+		return fmt.Sprintf("%v · daemon%v", programName, displaySleep(m))
+	}
+	return programName + displaySleep(m)
 }
 
-// functions used by view()
-// //////////////////////////////////////////////////////////////////////////////
 // helper function to format the appearance of devices in View(), takes model, device, and position on page
 func displayEntry(m *model, d PulseDevice, index int) string {
 	var s string
@@ -110,10 +148,10 @@ func displaySink(m *model, d PulseDevice) string {
 	case 1:
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + fmt.Sprintf("%v", m.Cursor.suff))
 	case 2:
-		t1 += cutText(fmt.Sprintf("%v%v%v", displayState(d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayState(d))))
+		t1 += cutText(fmt.Sprintf("%v%v%v%v%v", displayState(d), displayRecording(d), displayTree(m, d)+displayLock(m, d)+displayLink(m, d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayState(d))))
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + t1 + fmt.Sprintf("%v", m.Cursor.suff))
 	case 3:
-		t1 += cutText(fmt.Sprintf("%v%v", displayBattery(getBattery(d)), d.pulsedescription), m.StringLen)
+		t1 += cutText(fmt.Sprintf("%v%v%v%v", displayRecording(d), displayTree(m, d)+displayLock(m, d)+displayLink(m, d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen)
 		t2 += cutText(fmt.Sprintf("%vsink #%v %v %v", displayState(d), d.pulseindex, d.pulsesamplerate, d.pulseport), m.StringLen)
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref)+t1+fmt.Sprintf("%v", m.Cursor.suff)) + "\n"
 		s += m.Text.Render(t2)
@@ -129,11 +167,11 @@ func displayStream(m *model, d PulseDevice) string {
 	case 1:
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + fmt.Sprintf("%v", m.Cursor.suff))
 	case 2:
-		t1 += cutText(fmt.Sprintf("%v%v", displayStreamMute(d), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayStreamMute(d))))
+		t1 += cutText(fmt.Sprintf("%v%v%v", displayTree(m, d)+displayGroup(m, d)+displayMember(m, d)+displayStreamMute(d), displayLock(m, d)+displayLink(m, d), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayStreamMute(d))))
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + t1 + fmt.Sprintf("%v", m.Cursor.suff))
 	case 3:
-		t1 += cutText(fmt.Sprintf("%v", d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
-		t2 += cutText(fmt.Sprintf("%v%v #%v %v", displayStreamMute(d), d.pulsename, d.pulseindex, displaySinkPort(m, d.pulsesinkindex)), m.StringLen)
+		t1 += cutText(fmt.Sprintf("%v%v", displayTree(m, d)+displayGroup(m, d)+displayMember(m, d)+displayLock(m, d)+displayLink(m, d), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
+		t2 += cutText(fmt.Sprintf("%v%v %v %v", displayStreamMute(d), d.pulsename, displayStreamIndex(d), displaySinkPort(m, d.pulsesinkindex)), m.StringLen)
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref)+t1+fmt.Sprintf("%v", m.Cursor.suff)) + "\n"
 		s += m.Text.Render(t2)
 	}
@@ -148,10 +186,10 @@ func displaySource(m *model, d PulseDevice) string {
 	case 1:
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + fmt.Sprintf("%v", m.Cursor.suff))
 	case 2:
-		t1 += cutText(fmt.Sprintf("%v%v%v", displayState(d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayState(d))))
+		t1 += cutText(fmt.Sprintf("%v%v%v%v%v", displayState(d), displayRecording(d), displayTree(m, d)+displayLock(m, d)+displayLink(m, d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayState(d))))
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + t1 + fmt.Sprintf("%v", m.Cursor.suff))
 	case 3:
-		t1 += cutText(fmt.Sprintf("%v%v", displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
+		t1 += cutText(fmt.Sprintf("%v%v%v%v", displayRecording(d), displayTree(m, d)+displayLock(m, d)+displayLink(m, d), displayBattery(getBattery(d)), d.pulsedescription), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
 		t2 += cutText(fmt.Sprintf("%vsource #%v %v %v", displayState(d), d.pulseindex, d.pulsesamplerate, d.pulseport), m.StringLen)
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref)+t1+fmt.Sprintf("%v", m.Cursor.suff)) + "\n"
 		s += m.Text.Render(t2)
@@ -167,10 +205,10 @@ func displayOutput(m *model, d PulseDevice) string {
 	case 1:
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + fmt.Sprintf("%v", m.Cursor.suff))
 	case 2:
-		t1 += cutText(fmt.Sprintf("%v%v", displayOutputMute(d), d.pulsename), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayOutputMute(d))))
+		t1 += cutText(fmt.Sprintf("%v%v%v", displayOutputMute(d), displayTree(m, d)+displayLock(m, d)+displayLink(m, d), d.pulsename), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)+len(displayOutputMute(d))))
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref) + t1 + fmt.Sprintf("%v", m.Cursor.suff))
 	case 3:
-		t1 += cutText(fmt.Sprintf("%v", d.pulsename), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
+		t1 += cutText(fmt.Sprintf("%v%v", displayTree(m, d)+displayLock(m, d)+displayLink(m, d), d.pulsename), m.StringLen-(len(m.Cursor.pref)+len(m.Cursor.suff)+len(mute)))
 		t2 += cutText(fmt.Sprintf("%v#%v %v %v %vµs", displayOutputMute(d), d.pulseindex, displaySourceName(m, d.pulsesourceindex), d.pulsesamplerate, d.pulselatency), m.StringLen)
 		s += m.Text.Render(fmt.Sprintf("%v", m.Cursor.pref)+t1+fmt.Sprintf("%v", m.Cursor.suff)) + "\n"
 		s += m.Text.Render(t2)
@@ -182,12 +220,16 @@ func displayOutput(m *model, d PulseDevice) string {
 func displayProgressBars(m *model, d PulseDevice, index int) string {
 	var s string
 	var chosen bool
-	page := m.Paginator.Page * m.Paginator.PerPage
-	if index+page == m.Cursor.pos { // determine color
+	if index+m.Scroll == m.Cursor.pos { // determine color
 		chosen = true
 	}
-	for i := 0; i < d.pulsecount; i++ {
-		if m.ChannelMode == i && chosen {
+	order := channelOrder(d)
+	for k, i := range order {
+		if k > 0 { // blank line between bars, or the name of a new channel group
+			m.Text.Width(m.Width).Align(center).Foreground(toggleColor[0])
+			s += m.Text.Render(displayGroupDivider(d, order[k-1], i)) + "\n"
+		}
+		if (m.ChannelMode == i && chosen) || isTestChannel(m, d, i) {
 			m.Text.Width(m.Width).Align(center).Foreground(toggleColor[1])
 			var selected string // get adaptive color's string for progress
 			selected = toggleColor[1].Dark
@@ -205,15 +247,114 @@ func displayProgressBars(m *model, d PulseDevice, index int) string {
 			if hidePercentage == true {
 				d.bar[i].ShowPercentage = false
 			}
-			s += m.Text.Render(displayChannel(m, d, index, i)+d.bar[i].ViewAs(float64(d.pulsevolume[i])/float64(100))) + "\n\n"
+			s += m.Text.Render(displayChannel(m, d, index, i)+displayBar(m, d.bar[i], d, i)) + "\n"
 		} else {
 			m.Text.Width(m.Width).Align(center).Foreground(toggleColor[0])
-			s += m.Text.Render(displayChannel(m, d, index, i)+d.bar[i].ViewAs(float64(d.pulsevolume[i])/float64(100))) + "\n\n"
+			s += m.Text.Render(displayChannel(m, d, index, i)+displayBar(m, d.bar[i], d, i)) + "\n"
 		}
 	}
+	s += "\n"
+	if chosen && m.Layout && isSurround(d) {
+		m.Text.Width(m.Width).Align(center).Foreground(toggleColor[1])
+		for _, row := range displayLayout(m, d) {
+			s += m.Text.Render(row) + "\n"
+		}
+		s += "\n"
+	}
+	if chosen && m.Balance != balanceOff {
+		m.Text.Width(m.Width).Align(center).Foreground(toggleColor[1])
+		s += m.Text.Render(displayBalance(m, d)) + "\n\n"
+	}
+	if peak, ok := m.Peaks[meterKey(d)]; ok && m.Meters {
+		m.Text.Width(m.Width).Align(center).Foreground(toggleColor[0])
+		s += m.Text.Render(displayMeter(m, d, peak)) + "\n\n"
+	}
 	return s
 }
 
+// helper function to draw a volume bar with its label and the hardware 0 dB marker
+func displayBar(m *model, bar progress.Model, d PulseDevice, channel int) string {
+	label := ""
+	if bar.ShowPercentage {
+		label = volumeLabel(m, d, channel)
+		label += strings.Repeat(" ", labelWidth(m)-len([]rune(label)))
+	}
+	width := bar.Width - len([]rune(label))
+	if width < 1 {
+		return bar.PercentageStyle.Inline(true).Render(label)
+	}
+	// the scale reaches the volume limit, amplified volume is drawn past a 100% tick
+	scale := math.Max(100, volumeLimit(m, d))
+	filled := int(math.Round(float64(width) * math.Min(d.pulsevolume[channel]/scale, 1)))
+	filled = int(math.Max(0, float64(filled)))
+	marker, tick := -1, -1
+	if d.pulsebase > 0 && d.pulsebase < 100 {
+		marker = int(math.Round(float64(width-1) * d.pulsebase / scale))
+	}
+	if scale > 100 {
+		tick = int(math.Round(float64(width-1) * 100 / scale))
+	}
+	mark := bar.PercentageStyle.Inline(true)
+	full := lip.Foreground(lipgloss.Color(bar.FullColor))
+	warn := lip.Foreground(warningColor)
+	empty := lip.Foreground(lipgloss.Color(bar.EmptyColor))
+	// render runs of cells that share a style, markers replace one cell each
+	cell := func(i int) (int, lipgloss.Style, string) {
+		switch {
+		case i == marker:
+			return 0, mark, base_icon
+		case i == tick:
+			return 1, mark, tick_icon
+		case i < filled && tick >= 0 && i > tick:
+			return 2, warn, string(bar.Full)
+		case i < filled:
+			return 3, full, string(bar.Full)
+		}
+		return 4, empty, string(bar.Empty)
+	}
+	var s, run string
+	kind, style, _ := cell(0)
+	for i := 0; i < width; i++ {
+		k, next, text := cell(i)
+		if k != kind {
+			s += style.Render(run)
+			run = ""
+		}
+		kind, style = k, next
+		run += text
+	}
+	s += style.Render(run)
+	return s + bar.PercentageStyle.Inline(true).Render(label)
+}
+
+// helper function returns the width of volume labels in the current format
+func labelWidth(m *model) int {
+	return len([]rune(volumeLabel(m, PulseDevice{pulsevolume: []float64{100}, pulsedb: []float64{-100}}, 0)))
+}
+
+// helper function to draw a peak meter aligned with the progress bars of a device
+func displayMeter(m *model, d PulseDevice, peak float64) string {
+	label := "      " // same width as displayCursor() and displayChannel()
+	if m.Display.level == 0 {
+		label = ""
+	}
+	width := m.StringLen
+	suffix := ""
+	if len(d.bar) > 0 && d.bar[0].ShowPercentage { // leave room for bar label
+		suffix = strings.Repeat(" ", labelWidth(m))
+		width -= len(suffix)
+	}
+	if width < 1 {
+		return ""
+	}
+	filled := int(math.Round(math.Min(peak, 1) * float64(width)))
+	level := strings.Repeat("▪", filled) + strings.Repeat(" ", width-filled)
+	if istty || setNoSymbol {
+		level = strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	}
+	return label + level + suffix
+}
+
 // helper function to abbreviate/display channel label based on channel_map string
 func displayChannel(m *model, d PulseDevice, indexOnPage int, channel int) string {
 	if m.Display.level == 0 {
@@ -221,36 +362,20 @@ func displayChannel(m *model, d PulseDevice, indexOnPage int, channel int) strin
 	}
 	i := indexOnPage
 	c := channel
-	switch d.pulsechannels[c] {
-	case "mono":
-		return displayCursor(m, d, i, c) + "M  "
-	case "front-left":
-		return displayCursor(m, d, i, c) + "L  "
-	case "front-right":
-		return displayCursor(m, d, i, c) + "R  "
-	case "front-center":
-		return displayCursor(m, d, i, c) + "C  "
-	case "rear-left":
-		return displayCursor(m, d, i, c) + "l  "
-	case "rear-right":
-		return displayCursor(m, d, i, c) + "r  "
-	case "rear-center":
-		return displayCursor(m, d, i, c) + "c  "
-	default: // auxiliary channels if needed
-		return displayCursor(m, d, i, c) + "A  "
-		// TODO add cases for top-left/right/center and lfe
-	}
+	return displayCursor(m, d, i, c) + fmt.Sprintf("%-4v", channelLabel(d.pulsechannels[c]))
 }
 
 // helper function to display which channel is selected using changeChannel()
 func displayCursor(m *model, d PulseDevice, indexOnPage int, channel int) string {
-	cursor := "  "                                 // render nothing but allocate space
-	page := m.Paginator.Page * m.Paginator.PerPage // page in current model
-	if m.Cursor.pos == indexOnPage+page {          // cursor matches current device
+	cursor := "  "                            // render nothing but allocate space
+	if m.Cursor.pos == indexOnPage+m.Scroll { // cursor matches current device
 		if m.ChannelMode == channel { // mode matches channel number
 			cursor = "> " // render arrow in allocated space
 		}
 	}
+	if isTestChannel(m, d, channel) { // channel is playing the speaker test
+		cursor = "~ "
+	}
 	return cursor // show marker in view
 }
 
@@ -267,6 +392,9 @@ func displayToggledDevice(m *model) string {
 
 // helper function to prevent text from wrapping into newline
 func cutText(s string, max int) string {
+	if max <= 0 { // no room for any text
+		return ""
+	}
 	trunc := " .."
 	if len(s) < max+len(trunc) {
 		return s
@@ -276,7 +404,7 @@ func cutText(s string, max int) string {
 
 // helper function to get source name from index
 func displaySourceName(m *model, index int) string {
-	for _, v := range m.Device {
+	for _, v := range m.Server {
 		if v.pulseindex == index {
 			return v.pulsecard
 		}
@@ -286,7 +414,7 @@ func displaySourceName(m *model, index int) string {
 
 // helper function to get sink port from index
 func displaySinkPort(m *model, index int) string {
-	for _, v := range m.Device {
+	for _, v := range m.Server {
 		if v.pulseindex == index {
 			return fmt.Sprintf("sink #%v %v", v.pulseindex, v.pulseport)
 		}
@@ -341,6 +469,17 @@ func displayState(d PulseDevice) string {
 	return ""
 }
 
+// helper function to display a recording indicator and elapsed time
+func displayRecording(d PulseDevice) string {
+	elapsed, ok := recordingElapsed(d)
+	if !ok {
+		return ""
+	}
+	min := int(elapsed.Minutes())
+	sec := int(elapsed.Seconds()) % 60
+	return fmt.Sprintf("%v%02d:%02d ", rec_icon, min, sec)
+}
+
 // helper function returns battery property for pulsedevice
 func getBattery(d PulseDevice) string {
 	return d.pulsebattery
@@ -354,28 +493,33 @@ func displayBattery(status string) string {
 	if istty || setNoSymbol {
 		return status + " " // return the raw percentage string plus space
 	}
+	return batteryIcon(status, bluetooth_battery_icon)
+}
+
+// helper function picks the icon for a battery percentage string from an icon map
+func batteryIcon(status string, icons map[int]string) string {
 	var s string
 	b, _ := strconv.Atoi(strings.Trim(status, "%"))
 	if b > 89 {
-		s = bluetooth_battery_icon[90]
+		s = icons[90]
 	} else if b > 79 {
-		s = bluetooth_battery_icon[80]
+		s = icons[80]
 	} else if b > 69 {
-		s = bluetooth_battery_icon[70]
+		s = icons[70]
 	} else if b > 59 {
-		s = bluetooth_battery_icon[60]
+		s = icons[60]
 	} else if b > 49 {
-		s = bluetooth_battery_icon[50]
+		s = icons[50]
 	} else if b > 39 {
-		s = bluetooth_battery_icon[40]
+		s = icons[40]
 	} else if b > 29 {
-		s = bluetooth_battery_icon[30]
+		s = icons[30]
 	} else if b > 19 {
-		s = bluetooth_battery_icon[20]
+		s = icons[20]
 	} else if b > 9 {
-		s = bluetooth_battery_icon[10]
+		s = icons[10]
 	} else if b > 0 {
-		s = bluetooth_battery_icon[0]
+		s = icons[0]
 	}
 	return s
 }
@@ -392,8 +536,7 @@ func displayEmptyList(m *model) string {
 
 // helper function called in displayEntry() to establish the current selected device for highlighting
 func setChosen(m *model, d PulseDevice, index int) {
-	page := m.Paginator.Page * m.Paginator.PerPage
-	if index+page == m.Cursor.pos {
+	if index+m.Scroll == m.Cursor.pos {
 		m.Cursor.chosen = true
 		m.Cursor.pref = pref_icon
 		m.Cursor.suff = suff_icon
@@ -0,0 +1,103 @@
+// /////////////////////////////////////////////////////////////////////////////
+// DEVICE LIST SCROLLED TO FIT THE HEIGHT OF THE TERMINAL
+// /////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"fmt"                               // format and print text
+	"github.com/charmbracelet/lipgloss" // measure rendered lines
+)
+
+// lines a device takes in the list, worked out from the display level and
+// channel count as displayEntry lays it out, without rendering it
+func entryHeight(m *model, pos int) int {
+	d := m.Device[pos]
+	lines := 1 // title
+	if m.Display.level == 3 {
+		lines++ // subtitle
+	}
+	lines += 2 * len(d.pulsechannels) // each bar with a blank or divider line under it
+	if len(d.pulsechannels) == 0 {
+		lines++ // blank line closing the entry
+	}
+	if pos == m.Cursor.pos {
+		if m.Layout && isSurround(d) {
+			lines += len(displayLayout(m, d)) + 1
+		}
+		if m.Balance != balanceOff {
+			lines += 2
+		}
+	}
+	if _, ok := m.Peaks[meterKey(d)]; ok && m.Meters {
+		lines += 2
+	}
+	return lines
+}
+
+// running total of entry lines, lines[i] is the height of the first i devices
+func entryLines(m *model) []int {
+	lines := make([]int, m.Count.total-m.Count.cards+1)
+	for i := 1; i < len(lines); i++ {
+		lines[i] = lines[i-1] + entryHeight(m, i-1)
+	}
+	return lines
+}
+
+// lines left for devices once the title, tabs, messages, help and border are drawn
+func listHeight(m *model) int {
+	if m.Height == 0 { // size not known yet
+		return -1
+	}
+	c := *m
+	return m.Height - lipgloss.Height(m.Border.Render(displayHeader(&c)+displayFooter(&c, "")))
+}
+
+// check whether the devices from start up to end fit in the list height
+func fitsList(lines []int, start, end, height int) bool {
+	if setItems > 0 && end-start > setItems {
+		return false
+	}
+	return height < 0 || lines[end]-lines[start] <= height
+}
+
+// move the first device shown as little as needed to keep the cursor in view,
+// filling space under the last device by scrolling back up, and return the
+// device after the last one shown
+func scrollToCursor(m *model) int {
+	lines := entryLines(m)
+	total := len(lines) - 1
+	if m.Cursor.pos < 0 || total < 1 {
+		m.Scroll = 0
+		return 0
+	}
+	height := listHeight(m)
+	if m.Scroll > m.Cursor.pos {
+		m.Scroll = m.Cursor.pos
+	}
+	for m.Scroll < m.Cursor.pos && !fitsList(lines, m.Scroll, m.Cursor.pos+1, height) {
+		m.Scroll++
+	}
+	for m.Scroll > 0 && fitsList(lines, m.Scroll-1, total, height) {
+		m.Scroll--
+	}
+	end := m.Scroll + 1 // the cursor device is shown even when it is too tall
+	for end < total && fitsList(lines, m.Scroll, end+1, height) {
+		end++
+	}
+	return end
+}
+
+// devices shown, from the first one up to the one after the last
+func visibleRange(m *model) (int, int) {
+	end := scrollToCursor(m)
+	return m.Scroll, end
+}
+
+// devices shown out of all devices, nothing when they all fit or none are listed
+func displayPosition(m *model, start, end int) string {
+	total := m.Count.total - m.Count.cards
+	if end <= start || (start == 0 && end == total) {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(toggleColor[0]).Render(fmt.Sprintf("%v-%v of %v", start+1, end, total))
+}